@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// acceptHeaderTransport overrides the Accept header set by godo.
+type acceptHeaderTransport struct {
+	base   http.RoundTripper
+	accept string
+}
+
+func (t *acceptHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept", t.accept)
+
+	return t.base.RoundTrip(req)
+}
+
+// validateAcceptHeader checks that every media range of the Accept header
+// value is of the form type/subtype, optionally followed by parameters.
+func validateAcceptHeader(accept string) error {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil || !strings.Contains(mediaType, "/") {
+			return fmt.Errorf("invalid Accept header %q: %q is not a media type", accept, strings.TrimSpace(mediaRange))
+		}
+	}
+	return nil
+}
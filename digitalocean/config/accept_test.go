@@ -0,0 +1,74 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_AcceptHeader(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Accept   string
+		Expected string
+	}{
+		{
+			Name:     "default",
+			Expected: "application/json",
+		},
+		{
+			Name:     "override",
+			Accept:   "application/vnd.example+json; version=2",
+			Expected: "application/vnd.example+json; version=2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var accept string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accept = r.Header.Get("Accept")
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.AcceptHeader = tc.Accept
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+			if accept != tc.Expected {
+				t.Fatalf("Expected Accept %q, got %q", tc.Expected, accept)
+			}
+		})
+	}
+}
+
+func TestValidateAcceptHeader(t *testing.T) {
+	cases := []struct {
+		Accept string
+		Valid  bool
+	}{
+		{Accept: "application/json", Valid: true},
+		{Accept: "application/json, */*;q=0.8", Valid: true},
+		{Accept: "text/plain; charset=utf-8", Valid: true},
+		{Accept: "json", Valid: false},
+		{Accept: "application/json,", Valid: false},
+		{Accept: "application/json; =", Valid: false},
+	}
+
+	for _, tc := range cases {
+		err := validateAcceptHeader(tc.Accept)
+		if tc.Valid && err != nil {
+			t.Fatalf("Expected %q to be valid, got %s", tc.Accept, err)
+		}
+		if !tc.Valid && err == nil {
+			t.Fatalf("Expected %q to be invalid", tc.Accept)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// hostNotAllowedError is returned for requests to a host missing from
+// AllowedHosts. Such requests are never retried.
+type hostNotAllowedError struct {
+	host string
+}
+
+func (e *hostNotAllowedError) Error() string {
+	return fmt.Sprintf("requests to host %q are not allowed", e.host)
+}
+
+// allowedHostsTransport rejects requests to hosts outside of an allowlist. It
+// sits below the retrying transport so that redirects it follows are checked
+// as well.
+type allowedHostsTransport struct {
+	base  http.RoundTripper
+	hosts map[string]bool
+}
+
+func newAllowedHostsTransport(base http.RoundTripper, hosts []string) *allowedHostsTransport {
+	allowed := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		allowed[strings.ToLower(host)] = true
+	}
+	return &allowedHostsTransport{base: base, hosts: allowed}
+}
+
+// allows reports whether requests to the host of u are allowed.
+func (t *allowedHostsTransport) allows(u *url.URL) bool {
+	return t.hosts[strings.ToLower(u.Host)] || t.hosts[strings.ToLower(u.Hostname())]
+}
+
+func (t *allowedHostsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.allows(req.URL) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &hostNotAllowedError{host: req.URL.Host}
+	}
+
+	return t.base.RoundTrip(req)
+}
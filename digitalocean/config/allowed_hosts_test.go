@@ -0,0 +1,102 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_AllowedHosts(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+
+	cases := []struct {
+		Name      string
+		Allowed   []string
+		ExpectErr bool
+	}{
+		{
+			Name: "all hosts allowed",
+		},
+		{
+			Name:    "allowed host",
+			Allowed: []string{"api.digitalocean.com", serverURL.Hostname()},
+		},
+		{
+			Name:    "allowed host and port",
+			Allowed: []string{serverURL.Host},
+		},
+		{
+			Name:      "blocked host",
+			Allowed:   []string{"api.digitalocean.com"},
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+
+			c := testConfig(server.URL)
+			c.AllowedHosts = tc.Allowed
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+			if !tc.ExpectErr {
+				if err != nil {
+					t.Fatalf("Expected no error, got %s", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), "are not allowed") {
+				t.Fatalf("Expected the host to be blocked, got %v", err)
+			}
+			if n := atomic.LoadInt32(&requests); n != 0 {
+				t.Fatalf("Expected no request to reach the server, got %d", n)
+			}
+		})
+	}
+}
+
+func TestClient_AllowedHostsRedirect(t *testing.T) {
+	var redirected int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&redirected, 1)
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1)+r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+
+	c := testConfig(server.URL)
+	c.AllowedHosts = []string{serverURL.Host}
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err == nil {
+		t.Fatalf("Expected the redirect to be blocked")
+	}
+	if n := atomic.LoadInt32(&redirected); n != 0 {
+		t.Fatalf("Expected the redirect target not to be contacted, got %d requests", n)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// Authentication methods reported by CombinedConfig.AuthMethod, in order of
+// precedence.
+const (
+	AuthMethodTokenSource = "token_source"
+	AuthMethodOAuth       = "oauth"
+	AuthMethodTokenFile   = "token_file"
+	AuthMethodToken       = "token"
+)
+
+// errNoCredentials is returned when no authentication method is configured.
+var errNoCredentials = errors.New("no DigitalOcean API credentials configured: set token in the provider " +
+	"configuration, or the DIGITALOCEAN_TOKEN or DIGITALOCEAN_ACCESS_TOKEN environment variables")
+
+// resolveTokenSource returns the token source of the highest precedence
+// authentication method configured on c: TokenSource, then OAuthConfig, then
+// TokenFile, then Token. Tokens are refreshed with the HTTP client of ctx, if
+// any.
+func resolveTokenSource(ctx context.Context, c *Config) (oauth2.TokenSource, string, error) {
+	switch {
+	case c.TokenSource != nil:
+		return c.TokenSource, AuthMethodTokenSource, nil
+	case c.OAuthConfig != nil:
+		if c.RefreshToken == "" {
+			return nil, "", errors.New("a refresh token is required to use an OAuth configuration")
+		}
+		// Without an access token, the first request refreshes it.
+		return c.OAuthConfig.TokenSource(ctx, &oauth2.Token{RefreshToken: c.RefreshToken}), AuthMethodOAuth, nil
+	case c.TokenFile != "":
+		contents, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to read token file: %s", err)
+		}
+		token := strings.TrimSpace(string(contents))
+		if token == "" {
+			return nil, "", fmt.Errorf("token file %s is empty", c.TokenFile)
+		}
+		return staticTokenSource(token), AuthMethodTokenFile, nil
+	case c.Token != "":
+		return staticTokenSource(c.Token), AuthMethodToken, nil
+	}
+
+	return nil, "", errNoCredentials
+}
+
+// fallbackTokenSource returns the token of source, or the static token when
+// source fails.
+type fallbackTokenSource struct {
+	source oauth2.TokenSource
+	static oauth2.TokenSource
+}
+
+func (s *fallbackTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err == nil {
+		return token, nil
+	}
+
+	log.Printf("[WARN] Token source failed, falling back to the static token: %s", err)
+	return s.static.Token()
+}
+
+func staticTokenSource(token string) oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: token,
+	})
+}
+
+// AuthMethod returns the authentication method used by the client, one of
+// AuthMethodTokenSource, AuthMethodOAuth, AuthMethodTokenFile or
+// AuthMethodToken.
+func (c *CombinedConfig) AuthMethod() string {
+	return c.authMethod
+}
@@ -0,0 +1,309 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestClient_AuthMethod(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatalf("unable to write token file: %s", err)
+	}
+
+	cases := []struct {
+		Name          string
+		Token         string
+		TokenFile     string
+		TokenSource   bool
+		Expected      string
+		ExpectedToken string
+		ExpectErr     bool
+	}{
+		{
+			Name:      "none",
+			ExpectErr: true,
+		},
+		{
+			Name:          "token",
+			Token:         "static-token",
+			Expected:      AuthMethodToken,
+			ExpectedToken: "static-token",
+		},
+		{
+			Name:          "token file",
+			TokenFile:     tokenFile,
+			Expected:      AuthMethodTokenFile,
+			ExpectedToken: "file-token",
+		},
+		{
+			Name:          "token file over token",
+			Token:         "static-token",
+			TokenFile:     tokenFile,
+			Expected:      AuthMethodTokenFile,
+			ExpectedToken: "file-token",
+		},
+		{
+			Name:          "token source",
+			TokenSource:   true,
+			Expected:      AuthMethodTokenSource,
+			ExpectedToken: "source-token",
+		},
+		{
+			Name:          "token source over all",
+			Token:         "static-token",
+			TokenFile:     tokenFile,
+			TokenSource:   true,
+			Expected:      AuthMethodTokenSource,
+			ExpectedToken: "source-token",
+		},
+		{
+			Name:      "missing token file",
+			Token:     "static-token",
+			TokenFile: filepath.Join(t.TempDir(), "missing"),
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var authorization string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.Token = tc.Token
+			c.TokenFile = tc.TokenFile
+			if tc.TokenSource {
+				c.TokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "source-token"})
+			}
+
+			client, err := c.Client()
+			if tc.ExpectErr {
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			if method := client.AuthMethod(); method != tc.Expected {
+				t.Fatalf("Expected auth method %q, got %q", tc.Expected, method)
+			}
+
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+			if authorization != "Bearer "+tc.ExpectedToken {
+				t.Fatalf("Expected the %s to be sent, got %q", tc.ExpectedToken, authorization)
+			}
+		})
+	}
+}
+
+// flakyTokenSource fails the first failures calls to Token.
+type flakyTokenSource struct {
+	failures int32
+	calls    int32
+}
+
+func (s *flakyTokenSource) Token() (*oauth2.Token, error) {
+	if atomic.AddInt32(&s.calls, 1) <= s.failures {
+		return nil, errors.New("token service unavailable")
+	}
+	return &oauth2.Token{AccessToken: "source-token"}, nil
+}
+
+func TestClient_FallbackToStaticToken(t *testing.T) {
+	cases := []struct {
+		Name           string
+		Fallback       bool
+		ExpectErr      bool
+		ExpectedTokens []string
+	}{
+		{Name: "fallback", Fallback: true, ExpectedTokens: []string{"static-token", "source-token"}},
+		{Name: "no fallback", ExpectErr: true, ExpectedTokens: []string{"source-token"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var authorizations []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.Token = "static-token"
+			c.TokenSource = &flakyTokenSource{failures: 1}
+			c.FallbackToStaticToken = tc.Fallback
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+			if tc.ExpectErr {
+				if err == nil || !strings.Contains(err.Error(), "token service unavailable") {
+					t.Fatalf("Expected the token source error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			// The token source has recovered.
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			if len(authorizations) != len(tc.ExpectedTokens) {
+				t.Fatalf("Expected %d requests, got %q", len(tc.ExpectedTokens), authorizations)
+			}
+			for i, token := range tc.ExpectedTokens {
+				if authorizations[i] != "Bearer "+token {
+					t.Fatalf("Expected request %d to send the %s, got %q", i, token, authorizations[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_OAuthConfig(t *testing.T) {
+	cases := []struct {
+		Name              string
+		ExpiresIn         int
+		ExpectedRefreshes []string
+	}{
+		// Tokens expiring within 10 seconds are refreshed before use.
+		{Name: "expiring tokens", ExpiresIn: 1, ExpectedRefreshes: []string{"refresh-0", "refresh-1"}},
+		{Name: "valid tokens", ExpiresIn: 3600, ExpectedRefreshes: []string{"refresh-0"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var refreshes []string
+			tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "refresh_token" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				refreshes = append(refreshes, r.PostForm.Get("refresh_token"))
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"access-%d","token_type":"bearer","expires_in":%d,"refresh_token":"refresh-%d"}`,
+					len(refreshes), tc.ExpiresIn, len(refreshes))
+			}))
+			defer tokenServer.Close()
+
+			var authorizations []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.Token = ""
+			c.OAuthConfig = &oauth2.Config{
+				ClientID: "client-id",
+				Endpoint: oauth2.Endpoint{TokenURL: tokenServer.URL, AuthStyle: oauth2.AuthStyleInParams},
+			}
+			c.RefreshToken = "refresh-0"
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+			if method := client.AuthMethod(); method != AuthMethodOAuth {
+				t.Fatalf("Expected auth method %q, got %q", AuthMethodOAuth, method)
+			}
+
+			for i := 0; i < 2; i++ {
+				if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+					t.Fatalf("Expected no error, got %s", err)
+				}
+			}
+
+			if len(refreshes) != len(tc.ExpectedRefreshes) {
+				t.Fatalf("Expected refreshes with %q, got %q", tc.ExpectedRefreshes, refreshes)
+			}
+			for i, token := range tc.ExpectedRefreshes {
+				if refreshes[i] != token {
+					t.Fatalf("Expected refresh %d to use %s, got %s", i, token, refreshes[i])
+				}
+			}
+			if expected := fmt.Sprintf("Bearer access-%d", len(refreshes)); authorizations[1] != expected {
+				t.Fatalf("Expected the last request to send %q, got %q", expected, authorizations[1])
+			}
+		})
+	}
+}
+
+func TestClient_OAuthConfigWithoutRefreshToken(t *testing.T) {
+	c := testConfig("https://api.digitalocean.com")
+	c.OAuthConfig = &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: "https://cloud.digitalocean.com/v1/oauth/token"}}
+
+	if _, err := c.Client(); err == nil || !strings.Contains(err.Error(), "refresh token is required") {
+		t.Fatalf("Expected a missing refresh token error, got %v", err)
+	}
+}
+
+// countingTokenSource counts the calls to Token.
+type countingTokenSource struct {
+	calls int32
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return &oauth2.Token{AccessToken: "source-token"}, nil
+}
+
+func TestClient_DisableTokenReuse(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Disable       bool
+		ExpectedCalls int32
+	}{
+		{Name: "reuse", ExpectedCalls: 1},
+		{Name: "no reuse", Disable: true, ExpectedCalls: 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+
+			source := &countingTokenSource{}
+			c := testConfig(server.URL)
+			c.TokenSource = source
+			c.DisableTokenReuse = tc.Disable
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+					t.Fatalf("Expected no error, got %s", err)
+				}
+			}
+
+			if n := atomic.LoadInt32(&source.calls); n != tc.ExpectedCalls {
+				t.Fatalf("Expected %d token source calls, got %d", tc.ExpectedCalls, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// Rate limit headers returned by the DigitalOcean API.
+const (
+	headerRateLimit     = "RateLimit-Limit"
+	headerRateRemaining = "RateLimit-Remaining"
+	headerRateReset     = "RateLimit-Reset"
+	headerRetryAfter    = "Retry-After"
+)
+
+// BackoffStrategy selects the backoff used between retries.
+type BackoffStrategy string
+
+const (
+	// ExponentialBackoff doubles the wait after each attempt, starting at
+	// HTTPRetryWaitMin and capped by HTTPRetryWaitMax. This is the default.
+	ExponentialBackoff BackoffStrategy = "exponential"
+
+	// LinearJitterBackoff waits a random duration between HTTPRetryWaitMin
+	// and HTTPRetryWaitMax, multiplied by the attempt number.
+	LinearJitterBackoff BackoffStrategy = "linear_jitter"
+)
+
+// digitalOceanAPIBackoff waits for the rate limit window to reset when the
+// API responds with a 429 carrying a RateLimit-Reset header. Any other
+// response falls back to retryablehttp.DefaultBackoff.
+func digitalOceanAPIBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if sleep, ok := rateLimitResetSleep(resp); ok {
+			return sleep
+		}
+	}
+
+	return retryablehttp.DefaultBackoff(min, max, attemptNum, resp)
+}
+
+// rateLimitResetSleep returns how long to wait until the reset advertised by
+// the RateLimit-Reset header. It reports false when the header is missing,
+// malformed or already in the past.
+func rateLimitResetSleep(resp *http.Response) (time.Duration, bool) {
+	reset := resp.Header.Get(headerRateReset)
+	if reset == "" {
+		return 0, false
+	}
+
+	epoch, err := strconv.ParseInt(reset, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	sleep := time.Until(time.Unix(epoch, 0))
+	if sleep <= 0 {
+		return 0, false
+	}
+
+	return sleep, true
+}
+
+// isRateLimitResetExpired reports whether resp is a 429 whose
+// RateLimit-Reset is already in the past and that does not ask to wait with
+// Retry-After.
+func isRateLimitResetExpired(resp *http.Response) bool {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get(headerRetryAfter) != "" {
+		return false
+	}
+
+	epoch, err := strconv.ParseInt(resp.Header.Get(headerRateReset), 10, 64)
+	return err == nil && !time.Unix(epoch, 0).After(time.Now())
+}
+
+// hasRateLimitTiming reports whether the response tells the client when it
+// may try again.
+func hasRateLimitTiming(resp *http.Response) bool {
+	return resp.Header.Get(headerRateReset) != "" || resp.Header.Get(headerRetryAfter) != ""
+}
+
+// backoffPolicy applies the backoff options configured on Config on top of
+// digitalOceanAPIBackoff.
+type backoffPolicy struct {
+	strategy              BackoffStrategy
+	defaultRateLimitSleep time.Duration
+	maintenanceMaxWait    time.Duration
+	onMaintenance         func(eta time.Time)
+	probeThreshold        time.Duration
+	override              func(min, max time.Duration, attempt int, resp *http.Response) (time.Duration, bool)
+	minRetryInterval      time.Duration
+	schedule              []time.Duration
+	retryExpiredReset     bool
+	ignoreReset           bool
+}
+
+func newBackoffPolicy(c *Config) *backoffPolicy {
+	maintenanceMaxWait := c.MaintenanceMaxWait
+	if maintenanceMaxWait <= 0 {
+		maintenanceMaxWait = defaultMaintenanceMaxWait
+	}
+
+	var probeThreshold time.Duration
+	if c.ProbeAfterLongBackoff {
+		probeThreshold = c.LongBackoffThreshold
+		if probeThreshold <= 0 {
+			probeThreshold = defaultLongBackoffThreshold
+		}
+	}
+
+	return &backoffPolicy{
+		strategy:              c.BackoffStrategy,
+		defaultRateLimitSleep: c.DefaultRateLimitSleep,
+		maintenanceMaxWait:    maintenanceMaxWait,
+		onMaintenance:         c.OnMaintenance,
+		probeThreshold:        probeThreshold,
+		override:              c.OverrideBackoff,
+		minRetryInterval:      c.MinRetryInterval,
+		schedule:              c.BackoffSchedule,
+		retryExpiredReset:     c.ImmediateRetryAfterExpiredReset,
+		ignoreReset:           c.DisableResetAwareBackoff,
+	}
+}
+
+// Backoff satisfies retryablehttp.Backoff.
+func (p *backoffPolicy) Backoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	sleep := clampToSoftDeadline(p.backoff(min, max, attemptNum, resp), resp)
+
+	if state := responseState(resp); state != nil {
+		state.recordSleep(sleep)
+
+		if p.probeThreshold > 0 && sleep >= p.probeThreshold {
+			state.mu.Lock()
+			state.probe = true
+			state.mu.Unlock()
+		}
+	}
+
+	return sleep
+}
+
+func (p *backoffPolicy) backoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if p.override != nil {
+		if sleep, ok := p.override(min, max, attemptNum, resp); ok {
+			return sleep
+		}
+	}
+
+	sleep := p.defaultBackoff(min, max, attemptNum, resp)
+	if sleep < p.minRetryInterval {
+		sleep = p.minRetryInterval
+	}
+	return sleep
+}
+
+func (p *backoffPolicy) defaultBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if sleep, ok := p.maintenanceSleep(resp); ok {
+		return sleep
+	}
+
+	if len(p.schedule) > 0 {
+		return p.scheduledSleep(attemptNum, resp)
+	}
+
+	if p.retryExpiredReset && isRateLimitResetExpired(resp) {
+		return 0
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests &&
+		p.defaultRateLimitSleep > 0 && !hasRateLimitTiming(resp) {
+		sleep := p.defaultRateLimitSleep
+		if sleep > max {
+			sleep = max
+		}
+		return sleep
+	}
+
+	if p.strategy == LinearJitterBackoff {
+		if !p.ignoreReset && resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			if sleep, ok := rateLimitResetSleep(resp); ok {
+				return sleep
+			}
+		}
+		return retryablehttp.LinearJitterBackoff(min, max, attemptNum, resp)
+	}
+
+	if p.ignoreReset {
+		return retryablehttp.DefaultBackoff(min, max, attemptNum, resp)
+	}
+	return digitalOceanAPIBackoff(min, max, attemptNum, resp)
+}
+
+// scheduledSleep returns the entry of the schedule for attemptNum, repeating
+// the last entry for later attempts. A rate limit reset further away takes
+// precedence, unless the reset is ignored.
+func (p *backoffPolicy) scheduledSleep(attemptNum int, resp *http.Response) time.Duration {
+	i := attemptNum
+	if i >= len(p.schedule) {
+		i = len(p.schedule) - 1
+	}
+	sleep := p.schedule[i]
+
+	if !p.ignoreReset && resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if reset, ok := rateLimitResetSleep(resp); ok && reset > sleep {
+			return reset
+		}
+	}
+	return sleep
+}
+
+// ComputeBackoff returns how long the client waits before retrying attempt,
+// numbered from zero, after resp, which is nil when the attempt failed
+// without a response. It applies every backoff option of the client, but
+// does not count the wait in its statistics.
+func (c *CombinedConfig) ComputeBackoff(min, max time.Duration, attempt int, resp *http.Response) time.Duration {
+	return c.backoff.Backoff(min, max, attempt, resp)
+}
+
+// maintenanceSleep returns how long to wait for a maintenance window flagged
+// by the retry policy on the request state, capped by maintenanceMaxWait.
+func (p *backoffPolicy) maintenanceSleep(resp *http.Response) (time.Duration, bool) {
+	state := responseState(resp)
+	if state == nil {
+		return 0, false
+	}
+
+	state.mu.Lock()
+	eta := state.maintenanceUntil
+	state.maintenanceUntil = time.Time{}
+	state.mu.Unlock()
+
+	if eta.IsZero() {
+		return 0, false
+	}
+
+	sleep := time.Until(eta)
+	if sleep <= 0 {
+		return 0, false
+	}
+	if sleep > p.maintenanceMaxWait {
+		sleep = p.maintenanceMaxWait
+	}
+
+	if p.onMaintenance != nil {
+		p.onMaintenance(eta)
+	}
+
+	return sleep, true
+}
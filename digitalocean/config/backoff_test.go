@@ -0,0 +1,525 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testResponse builds a response with the given status code and headers.
+func testResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+	}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func resetIn(d time.Duration) string {
+	return strconv.FormatInt(time.Now().Add(d).Unix(), 10)
+}
+
+func TestDigitalOceanAPIBackoff(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+
+	cases := []struct {
+		Name     string
+		Resp     *http.Response
+		Attempt  int
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{
+			Name:     "no response",
+			Attempt:  1,
+			Expected: 2 * time.Second,
+		},
+		{
+			Name:     "server error",
+			Resp:     testResponse(http.StatusInternalServerError, nil),
+			Attempt:  2,
+			Expected: 4 * time.Second,
+		},
+		{
+			Name:     "rate limited with reset",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(10 * time.Second)}),
+			Expected: 10 * time.Second,
+			Delta:    time.Second,
+		},
+		{
+			Name:     "rate limited with past reset",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(-10 * time.Second)}),
+			Expected: time.Second,
+		},
+		{
+			Name:     "rate limited with retry after",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRetryAfter: "7"}),
+			Expected: 7 * time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			sleep := digitalOceanAPIBackoff(min, max, tc.Attempt, tc.Resp)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+}
+
+func TestBackoff_DefaultRateLimitSleep(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+
+	cases := []struct {
+		Name     string
+		Sleep    time.Duration
+		Max      time.Duration
+		Resp     *http.Response
+		Expected time.Duration
+	}{
+		{
+			Name:     "headerless 429",
+			Sleep:    5 * time.Second,
+			Resp:     testResponse(http.StatusTooManyRequests, nil),
+			Expected: 5 * time.Second,
+		},
+		{
+			Name:     "capped by max",
+			Sleep:    time.Minute,
+			Resp:     testResponse(http.StatusTooManyRequests, nil),
+			Expected: max,
+		},
+		{
+			Name:     "retry after takes precedence",
+			Sleep:    5 * time.Second,
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRetryAfter: "3"}),
+			Expected: 3 * time.Second,
+		},
+		{
+			Name:     "not rate limited",
+			Sleep:    5 * time.Second,
+			Resp:     testResponse(http.StatusBadGateway, nil),
+			Expected: min,
+		},
+		{
+			Name:     "unset",
+			Resp:     testResponse(http.StatusTooManyRequests, nil),
+			Expected: min,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			policy := newBackoffPolicy(&Config{DefaultRateLimitSleep: tc.Sleep})
+			if sleep := policy.Backoff(min, max, 0, tc.Resp); sleep != tc.Expected {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+}
+
+func TestBackoff_LinearJitterStrategy(t *testing.T) {
+	min, max := time.Second, 2*time.Second
+	policy := newBackoffPolicy(&Config{BackoffStrategy: LinearJitterBackoff})
+
+	sleep := policy.Backoff(min, max, 1, testResponse(http.StatusBadGateway, nil))
+	if sleep < 2*min || sleep > 2*max {
+		t.Fatalf("Expected a sleep between %s and %s, got %s", 2*min, 2*max, sleep)
+	}
+
+	reset := testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(10 * time.Second)})
+	if sleep := policy.Backoff(min, max, 1, reset); sleep < 8*time.Second {
+		t.Fatalf("Expected the rate limit reset to take precedence, got %s", sleep)
+	}
+}
+
+func TestClient_InvalidBackoffStrategy(t *testing.T) {
+	conf := testConfig("https://api.digitalocean.com")
+	conf.BackoffStrategy = "random"
+
+	if _, err := conf.Client(); err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
+
+func TestBackoff_OverrideBackoff(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+	rateLimited := testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(10 * time.Second)})
+
+	cases := []struct {
+		Name     string
+		Override func(min, max time.Duration, attempt int, resp *http.Response) (time.Duration, bool)
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{
+			Name: "override",
+			Override: func(min, max time.Duration, attempt int, resp *http.Response) (time.Duration, bool) {
+				return 250 * time.Millisecond, resp.StatusCode == http.StatusTooManyRequests
+			},
+			Expected: 250 * time.Millisecond,
+		},
+		{
+			Name: "passthrough",
+			Override: func(min, max time.Duration, attempt int, resp *http.Response) (time.Duration, bool) {
+				return 250 * time.Millisecond, false
+			},
+			Expected: 10 * time.Second,
+			Delta:    time.Second,
+		},
+		{
+			Name:     "unset",
+			Expected: 10 * time.Second,
+			Delta:    time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			policy := newBackoffPolicy(&Config{OverrideBackoff: tc.Override})
+			sleep := policy.Backoff(min, max, 1, rateLimited)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+}
+
+func TestBackoff_MinRetryInterval(t *testing.T) {
+	min, max := time.Millisecond, 30*time.Second
+
+	cases := []struct {
+		Name     string
+		Resp     *http.Response
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{
+			Name:     "raises a short backoff",
+			Resp:     testResponse(http.StatusInternalServerError, nil),
+			Expected: 2 * time.Second,
+		},
+		{
+			Name:     "raises a short rate limit wait",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRetryAfter: "1"}),
+			Expected: 2 * time.Second,
+		},
+		{
+			Name:     "keeps a longer rate limit wait",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(10 * time.Second)}),
+			Expected: 10 * time.Second,
+			Delta:    time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			policy := newBackoffPolicy(&Config{MinRetryInterval: 2 * time.Second})
+			sleep := policy.Backoff(min, max, 0, tc.Resp)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+}
+
+func TestClient_MinRetryInterval(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.HTTPRetryMax = 2
+	conf.MinRetryInterval = 50 * time.Millisecond
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	if len(times) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < conf.MinRetryInterval {
+			t.Fatalf("Expected attempts at least %s apart, got %s between %d and %d", conf.MinRetryInterval, gap, i-1, i)
+		}
+	}
+}
+
+func TestBackoff_BackoffSchedule(t *testing.T) {
+	min, max := time.Millisecond, 10*time.Millisecond
+	schedule := []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 2 * time.Minute}
+	policy := newBackoffPolicy(&Config{BackoffSchedule: schedule})
+	unavailable := testResponse(http.StatusServiceUnavailable, nil)
+
+	expected := append(append([]time.Duration(nil), schedule...), 2*time.Minute, 2*time.Minute)
+	for attempt, want := range expected {
+		if sleep := policy.Backoff(min, max, attempt, unavailable); sleep != want {
+			t.Fatalf("Expected attempt %d to sleep %s, got %s", attempt, want, sleep)
+		}
+	}
+
+	cases := []struct {
+		Name     string
+		Resp     *http.Response
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{
+			Name:     "longer rate limit reset",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(10 * time.Second)}),
+			Expected: 10 * time.Second,
+			Delta:    time.Second,
+		},
+		{
+			Name:     "shorter rate limit reset",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(2 * time.Second)}),
+			Expected: 5 * time.Second,
+		},
+		{
+			Name:     "connection error",
+			Expected: 5 * time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			sleep := policy.Backoff(min, max, 1, tc.Resp)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+}
+
+func TestCombinedConfig_ComputeBackoff(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+
+	cases := []struct {
+		Name     string
+		Modify   func(c *Config)
+		Resp     *http.Response
+		Attempt  int
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{
+			Name:     "no response",
+			Attempt:  1,
+			Expected: 2 * time.Second,
+		},
+		{
+			Name:     "server error",
+			Resp:     testResponse(http.StatusInternalServerError, nil),
+			Attempt:  2,
+			Expected: 4 * time.Second,
+		},
+		{
+			Name:     "rate limited with reset",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(10 * time.Second)}),
+			Expected: 10 * time.Second,
+			Delta:    time.Second,
+		},
+		{
+			Name:     "rate limited with retry after",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRetryAfter: "7"}),
+			Expected: 7 * time.Second,
+		},
+		{
+			Name:     "headerless 429 with default sleep",
+			Modify:   func(c *Config) { c.DefaultRateLimitSleep = 5 * time.Second },
+			Resp:     testResponse(http.StatusTooManyRequests, nil),
+			Expected: 5 * time.Second,
+		},
+		{
+			Name:     "minimum retry interval",
+			Modify:   func(c *Config) { c.MinRetryInterval = 3 * time.Second },
+			Resp:     testResponse(http.StatusBadGateway, nil),
+			Expected: 3 * time.Second,
+		},
+		{
+			Name:     "schedule",
+			Modify:   func(c *Config) { c.BackoffSchedule = []time.Duration{time.Minute} },
+			Resp:     testResponse(http.StatusBadGateway, nil),
+			Attempt:  3,
+			Expected: time.Minute,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			if tc.Modify != nil {
+				tc.Modify(c)
+			}
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			sleep := client.ComputeBackoff(min, max, tc.Attempt, tc.Resp)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+			if backoff := client.Summary().Backoff; backoff != 0 {
+				t.Fatalf("Expected the computed backoff not to be counted, got %s", backoff)
+			}
+		})
+	}
+}
+
+func TestBackoff_ImmediateRetryAfterExpiredReset(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+
+	cases := []struct {
+		Name     string
+		Enabled  bool
+		Resp     *http.Response
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{
+			Name:     "past reset",
+			Enabled:  true,
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(-10 * time.Second)}),
+			Expected: 0,
+		},
+		{
+			Name:     "past reset disabled",
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(-10 * time.Second)}),
+			Expected: 2 * time.Second,
+		},
+		{
+			Name:     "future reset",
+			Enabled:  true,
+			Resp:     testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(10 * time.Second)}),
+			Expected: 10 * time.Second,
+			Delta:    time.Second,
+		},
+		{
+			Name:    "past reset with retry after",
+			Enabled: true,
+			Resp: testResponse(http.StatusTooManyRequests, map[string]string{
+				headerRateReset:  resetIn(-10 * time.Second),
+				headerRetryAfter: "7",
+			}),
+			Expected: 7 * time.Second,
+		},
+		{
+			Name:     "not rate limited",
+			Enabled:  true,
+			Resp:     testResponse(http.StatusBadGateway, map[string]string{headerRateReset: resetIn(-10 * time.Second)}),
+			Expected: 2 * time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			policy := newBackoffPolicy(&Config{ImmediateRetryAfterExpiredReset: tc.Enabled})
+			sleep := policy.Backoff(min, max, 1, tc.Resp)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+}
+
+func TestBackoff_DisableResetAwareBackoff(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+	reset := testResponse(http.StatusTooManyRequests, map[string]string{headerRateReset: resetIn(time.Minute)})
+
+	cases := []struct {
+		Name     string
+		Config   Config
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{
+			Name:     "exponential",
+			Config:   Config{DisableResetAwareBackoff: true},
+			Expected: 2 * time.Second,
+		},
+		{
+			Name:     "schedule",
+			Config:   Config{DisableResetAwareBackoff: true, BackoffSchedule: []time.Duration{time.Second, 5 * time.Second}},
+			Expected: 5 * time.Second,
+		},
+		{
+			Name:     "reset aware",
+			Expected: time.Minute,
+			Delta:    time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			policy := newBackoffPolicy(&tc.Config)
+			sleep := policy.Backoff(min, max, 1, reset)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+
+	linear := newBackoffPolicy(&Config{DisableResetAwareBackoff: true, BackoffStrategy: LinearJitterBackoff})
+	if sleep := linear.Backoff(min, 2*time.Second, 1, reset); sleep > 4*time.Second {
+		t.Fatalf("Expected the linear jitter backoff to ignore the reset, got %s", sleep)
+	}
+}
+
+func TestClient_DisableResetAwareBackoff(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateReset, resetIn(time.Hour))
+		if atomic.AddInt32(&attempts, 1) <= 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.DisableResetAwareBackoff = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	// A reset an hour away would fail fast against the deadline if it were
+	// honored.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := client.GodoClient().NewRequest(ctx, http.MethodGet, "/v2/account", nil)
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+	if _, err := client.GodoClient().Do(ctx, req, nil); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 4 {
+		t.Fatalf("Expected 4 attempts, got %d", n)
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// baseContextTransport cancels requests when the base context of the client
+// is done. It must wrap the retrying transport so that retries stop as well.
+type baseContextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *baseContextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(stop)
+			cancel()
+		})
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		release()
+		return resp, err
+	}
+
+	// Keep the request context alive until the body has been consumed.
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose calls release once the body is closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// BaseContext returns the context set with Config.ClientContext, which bounds
+// every request made by the client.
+func (c *CombinedConfig) BaseContext() context.Context {
+	return c.baseContext
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientContext_CancelsInFlightRequests(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := testConfig(server.URL).ClientContext(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if client.BaseContext() != ctx {
+		t.Fatalf("Expected the base context to be kept")
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+		errs <- err
+	}()
+
+	<-received
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the in-flight request to be aborted")
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected new requests to fail once the base context is done, got %v", err)
+	}
+}
+
+func TestClientContext_ReadsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"account": {"email": "sammy@example.com"}}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := testConfig(server.URL).ClientContext(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	account, _, err := client.GodoClient().Account.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if account.Email != "sammy@example.com" {
+		t.Fatalf("Expected the account to be decoded, got %q", account.Email)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// bodyBufferTransport reads response bodies while the request can still be
+// retried, so that a connection reset or truncated body is reported as a
+// retryable transport error rather than surfacing later while godo decodes
+// the response. Bodies larger than max are only buffered up to that size and
+// streamed afterwards, and are then no longer retried when truncated. Only
+// requests that are safe to repeat, by their method or an Idempotency-Key
+// header, are retried: a truncated body of a POST is returned as is, since
+// the request has taken effect. It sits below the retrying transport and
+// sees every attempt.
+type bodyBufferTransport struct {
+	base http.RoundTripper
+	max  int64
+}
+
+func (t *bodyBufferTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, err
+	}
+
+	if resp.ContentLength > t.max {
+		return resp, nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, t.max+1))
+	if err != nil {
+		resp.Body.Close()
+		if !isRetrySafeMethod(req.Method) && req.Header.Get(idempotencyKeyHeader) == "" {
+			resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(buf), &errorReader{err: err}))
+			return resp, nil
+		}
+		return nil, fmt.Errorf("reading response body of %s %s: %w", req.Method, req.URL, err)
+	}
+
+	if int64(len(buf)) <= t.max {
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(buf))
+		return resp, nil
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+	return resp, nil
+}
+
+// errorReader fails every read with err.
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestClient_RetriesTruncatedBody(t *testing.T) {
+	const body = `{"account": {"email": "sammy@example.com", "status": "active"}}`
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			// Announce the full body but close the connection halfway.
+			w.Header().Set("Content-Length", "64")
+			w.Write([]byte(body[:20]))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	account, _, err := client.GodoClient().Account.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the truncated response to be retried, got %s", err)
+	}
+	if account.Email != "sammy@example.com" {
+		t.Fatalf("Expected the account to be decoded, got %q", account.Email)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", n)
+	}
+}
+
+func TestClient_TruncatedBodyOfPostNotRetried(t *testing.T) {
+	cases := []struct {
+		Name             string
+		IdempotencyKey   bool
+		ExpectedAttempts int32
+	}{
+		{Name: "without idempotency key", ExpectedAttempts: 1},
+		{Name: "with idempotency key", IdempotencyKey: true, ExpectedAttempts: 4},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Length", "64")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"droplet": {"id": 1`))
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.EnableIdempotencyKeys = tc.IdempotencyKey
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			_, _, err = client.GodoClient().Droplets.Create(context.Background(), &godo.DropletCreateRequest{Name: "web"})
+			if err == nil {
+				t.Fatal("Expected the truncated body to fail decoding")
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
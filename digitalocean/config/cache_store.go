@@ -0,0 +1,122 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheStore persists the entries of the client's response caches. A store
+// shared between runs, such as a FileCacheStore, lets cache entries survive
+// across Terraform invocations. Implementations must be safe for concurrent
+// use.
+type CacheStore interface {
+	// Get returns the value stored under key, and false when there is none.
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+}
+
+// MemoryCacheStore is a CacheStore that keeps entries in memory for the
+// lifetime of the process.
+type MemoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string][]byte
+}
+
+// NewMemoryCacheStore returns an empty MemoryCacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: map[string][]byte{}}
+}
+
+func (s *MemoryCacheStore) Get(key string) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.entries[key]
+	return value, ok, nil
+}
+
+func (s *MemoryCacheStore) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *MemoryCacheStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// FileCacheStore is a CacheStore that keeps each entry in a file of a
+// directory, named after the SHA-256 of its key. Entries hold whole response
+// bodies and do not expire, so the directory and its files are only
+// readable by the current user.
+type FileCacheStore struct {
+	dir string
+}
+
+// NewFileCacheStore returns a FileCacheStore writing to dir, which is
+// created if needed.
+func NewFileCacheStore(dir string) (*FileCacheStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileCacheStore{dir: dir}, nil
+}
+
+func (s *FileCacheStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *FileCacheStore) Get(key string) ([]byte, bool, error) {
+	value, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set writes the entry to a temporary file first, so that concurrent readers
+// never see a partial entry.
+func (s *FileCacheStore) Set(key string, value []byte) error {
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+func (s *FileCacheStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheStores(t *testing.T) {
+	fileStore, err := NewFileCacheStore(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stores := map[string]CacheStore{
+		"memory": NewMemoryCacheStore(),
+		"file":   fileStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, ok, err := store.Get("https://api.digitalocean.com/v2/account"); ok || err != nil {
+				t.Fatalf("Expected no entry, got %t, %v", ok, err)
+			}
+
+			if err := store.Set("https://api.digitalocean.com/v2/account", []byte("first")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err := store.Set("https://api.digitalocean.com/v2/account", []byte("second")); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			value, ok, err := store.Get("https://api.digitalocean.com/v2/account")
+			if err != nil || !ok || string(value) != "second" {
+				t.Fatalf("Expected the last value to be stored, got %q, %t, %v", value, ok, err)
+			}
+
+			if err := store.Delete("https://api.digitalocean.com/v2/account"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if _, ok, err := store.Get("https://api.digitalocean.com/v2/account"); ok || err != nil {
+				t.Fatalf("Expected the entry to be deleted, got %t, %v", ok, err)
+			}
+			if err := store.Delete("https://api.digitalocean.com/v2/account"); err != nil {
+				t.Fatalf("Expected deleting a missing entry to succeed, got %s", err)
+			}
+		})
+	}
+}
+
+func TestFileCacheStore_Persists(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := NewFileCacheStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := first.Set("key", []byte("value")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	second, err := NewFileCacheStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if value, ok, err := second.Get("key"); err != nil || !ok || string(value) != "value" {
+		t.Fatalf("Expected the entry to persist, got %q, %t, %v", value, ok, err)
+	}
+}
+
+func TestFileCacheStore_Private(t *testing.T) {
+	store, err := NewFileCacheStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := store.Set("key", []byte("value")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	info, err := os.Stat(store.path("key"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("Expected the entry to be created 0600, got %o", mode)
+	}
+}
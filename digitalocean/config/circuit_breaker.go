@@ -0,0 +1,152 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests rejected while the circuit breaker
+// is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open: the DigitalOcean API is failing consistently")
+
+// defaultCircuitBreakerCooldown is used when CircuitBreakerCooldown is unset.
+const defaultCircuitBreakerCooldown = 30 * time.Second
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops sending requests after a run of consecutive failures
+// and lets a single trial request through once the cooldown has passed.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	onOpen    func(reason error)
+	onClose   func()
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(c *Config) *circuitBreaker {
+	if c.CircuitBreakerThreshold <= 0 {
+		return nil
+	}
+
+	cooldown := c.CircuitBreakerCooldown
+	if cooldown <= 0 {
+		cooldown = defaultCircuitBreakerCooldown
+	}
+
+	return &circuitBreaker{
+		threshold: c.CircuitBreakerThreshold,
+		cooldown:  cooldown,
+		onOpen:    c.OnCircuitOpen,
+		onClose:   c.OnCircuitClose,
+	}
+}
+
+// allow reports whether a request may be sent.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// Only the trial request is let through.
+		return false
+	}
+	return true
+}
+
+// record updates the breaker with the outcome of a request.
+func (b *circuitBreaker) record(failure error) {
+	b.mu.Lock()
+
+	var opened, closed bool
+	if failure == nil {
+		closed = b.state != circuitClosed
+		b.state = circuitClosed
+		b.failures = 0
+	} else {
+		b.failures++
+		if b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.threshold) {
+			opened = true
+			b.state = circuitOpen
+			b.openedAt = time.Now()
+		}
+	}
+
+	b.mu.Unlock()
+
+	if opened && b.onOpen != nil {
+		b.onOpen(failure)
+	}
+	if closed && b.onClose != nil {
+		b.onClose()
+	}
+}
+
+// release gives up the trial request of a half-open breaker without
+// recording an outcome, so that the next request is let through as the
+// trial instead.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		// The cooldown has already passed since openedAt.
+		b.state = circuitOpen
+	}
+}
+
+// circuitFailure classifies the outcome of a request. Server errors and
+// transport errors count as failures; client errors, including 429s,
+// do not.
+func circuitFailure(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	return nil
+}
+
+// circuitBreakerTransport fails fast while the circuit breaker is open.
+type circuitBreakerTransport struct {
+	base    http.RoundTripper
+	breaker *circuitBreaker
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if errors.Is(err, context.Canceled) {
+		// A canceled request says nothing about the health of the API.
+		t.breaker.release()
+		return resp, err
+	}
+	t.breaker.record(circuitFailure(resp, err))
+
+	return resp, err
+}
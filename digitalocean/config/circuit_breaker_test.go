@@ -0,0 +1,155 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	var failing int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var opened, closed int32
+	var reason error
+
+	conf := testConfig(server.URL)
+	conf.HTTPRetryMax = 0
+	conf.CircuitBreakerThreshold = 2
+	conf.CircuitBreakerCooldown = 50 * time.Millisecond
+	conf.OnCircuitOpen = func(err error) {
+		atomic.AddInt32(&opened, 1)
+		reason = err
+	}
+	conf.OnCircuitClose = func() {
+		atomic.AddInt32(&closed, 1)
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	godoClient := client.GodoClient()
+
+	// Two consecutive failures trip the breaker.
+	for i := 0; i < 2; i++ {
+		doRequest(t, godoClient, http.MethodGet, "/v2/account")
+	}
+	if opened != 1 || reason == nil {
+		t.Fatalf("Expected OnCircuitOpen to fire once with a reason, fired %d times", opened)
+	}
+
+	// While open, requests fail fast.
+	if _, err := doRequest(t, godoClient, http.MethodGet, "/v2/account"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	// After the cooldown a successful trial request closes it again.
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := doRequest(t, godoClient, http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if closed != 1 {
+		t.Fatalf("Expected OnCircuitClose to fire once, fired %d times", closed)
+	}
+	if _, err := doRequest(t, godoClient, http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	var opened int32
+	conf := &Config{
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerCooldown:  time.Millisecond,
+		OnCircuitOpen:           func(error) { atomic.AddInt32(&opened, 1) },
+	}
+	breaker := newCircuitBreaker(conf)
+
+	breaker.record(errors.New("boom"))
+	time.Sleep(2 * time.Millisecond)
+
+	if !breaker.allow() {
+		t.Fatal("Expected a trial request after the cooldown")
+	}
+	if breaker.allow() {
+		t.Fatal("Expected only one trial request while half-open")
+	}
+
+	breaker.record(errors.New("still failing"))
+	if opened != 2 {
+		t.Fatalf("Expected the breaker to reopen, OnCircuitOpen fired %d times", opened)
+	}
+}
+
+func TestCircuitBreaker_CanceledRequest(t *testing.T) {
+	var opened, closed int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	conf := &Config{
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerCooldown:  time.Millisecond,
+		OnCircuitOpen:           func(error) { atomic.AddInt32(&opened, 1) },
+		OnCircuitClose:          func() { atomic.AddInt32(&closed, 1) },
+	}
+	breaker := newCircuitBreaker(conf)
+	transport := &circuitBreakerTransport{base: http.DefaultTransport, breaker: breaker}
+
+	breaker.record(errors.New("boom"))
+	time.Sleep(2 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// The canceled trial neither closes nor reopens the breaker, and the
+	// next request is let through as the trial.
+	if opened != 1 || closed != 0 {
+		t.Fatalf("Expected no transition, OnCircuitOpen fired %d times and OnCircuitClose %d times", opened, closed)
+	}
+	if !breaker.allow() {
+		t.Fatal("Expected a new trial request after a canceled one")
+	}
+	if breaker.allow() {
+		t.Fatal("Expected only one trial request while half-open")
+	}
+}
+
+func TestCircuitBreaker_NilHooks(t *testing.T) {
+	breaker := newCircuitBreaker(&Config{CircuitBreakerThreshold: 1, CircuitBreakerCooldown: time.Millisecond})
+
+	breaker.record(errors.New("boom"))
+	time.Sleep(2 * time.Millisecond)
+	breaker.allow()
+	breaker.record(nil)
+}
+
+func TestCircuitFailure(t *testing.T) {
+	if circuitFailure(testResponse(http.StatusTooManyRequests, nil), nil) != nil {
+		t.Error("Expected a 429 not to count as a failure")
+	}
+	if circuitFailure(testResponse(http.StatusNotFound, nil), nil) != nil {
+		t.Error("Expected a 404 not to count as a failure")
+	}
+	if circuitFailure(testResponse(http.StatusBadGateway, nil), nil) == nil {
+		t.Error("Expected a 502 to count as a failure")
+	}
+}
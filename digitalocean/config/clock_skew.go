@@ -0,0 +1,45 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// clockSkewPath is the endpoint queried when measuring clock skew. Any
+// authenticated endpoint works; the account is small and always available.
+const clockSkewPath = "/v2/account"
+
+// CheckClockSkew measures the difference between the API's clock, as
+// reported by the Date response header, and the local clock. A positive
+// value means the server is ahead. The reset-aware backoff compares local
+// time against server timestamps, so large skews degrade its accuracy.
+func (c *CombinedConfig) CheckClockSkew(ctx context.Context) (time.Duration, error) {
+	req, err := c.client.NewRequest(ctx, http.MethodGet, clockSkewPath, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := time.Now()
+	resp, err := c.client.Do(ctx, req, nil)
+	received := time.Now()
+	if resp == nil || resp.Response == nil {
+		return 0, fmt.Errorf("unable to check clock skew: %s", err)
+	}
+
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return 0, fmt.Errorf("unable to check clock skew: response has no Date header")
+	}
+
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0, fmt.Errorf("unable to check clock skew: invalid Date header %q: %s", date, err)
+	}
+
+	// Assume the server stamped the response halfway through the round trip.
+	local := sent.Add(received.Sub(sent) / 2)
+
+	return serverTime.Sub(local), nil
+}
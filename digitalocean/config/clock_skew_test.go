@@ -0,0 +1,50 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckClockSkew(t *testing.T) {
+	offset := time.Hour
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	skew, err := client.CheckClockSkew(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The Date header only has one second resolution.
+	if diff := skew - offset; diff < -2*time.Second || diff > 2*time.Second {
+		t.Fatalf("Expected a skew of about %s, got %s", offset, skew)
+	}
+}
+
+func TestCheckClockSkew_InvalidDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "not a date")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := client.CheckClockSkew(context.Background()); err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalesceTransport shares a single round trip between identical GET
+// requests in flight at the same time. Every caller receives its own copy of
+// the response. It sits below the OAuth transport so that requests made with
+// different credentials are never coalesced.
+type coalesceTransport struct {
+	base  http.RoundTripper
+	group singleflight.Group
+}
+
+// coalescedResponse is the response of a shared round trip, with its body read
+// in full so that it can be handed to every caller.
+type coalescedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+func (t *coalesceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || (req.Body != nil && req.Body != http.NoBody) {
+		return t.base.RoundTrip(req)
+	}
+
+	key := requestCacheKey(req) + " " + req.Header.Get("Accept")
+	v, err, shared := t.group.Do(key, func() (interface{}, error) {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		return &coalescedResponse{resp: resp, body: body}, nil
+	})
+	if err != nil {
+		// The round trip was canceled by the context of another caller.
+		if shared && req.Context().Err() == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			return t.base.RoundTrip(req)
+		}
+		return nil, err
+	}
+
+	coalesced := v.(*coalescedResponse)
+	resp := new(http.Response)
+	*resp = *coalesced.resp
+	resp.Header = coalesced.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(coalesced.body))
+	resp.Request = req
+	return resp, nil
+}
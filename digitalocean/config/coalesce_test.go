@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_CoalesceGETs(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, `{"vpc":{"id":"vpc-1","name":"shared"}}`)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.CoalesceGETs = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	names := make([]string, 10)
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			vpc, _, err := client.GodoClient().VPCs.Get(context.Background(), "vpc-1")
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			names[i] = vpc.Name
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("Expected a single request, got %d", n)
+	}
+	for i, name := range names {
+		if name != "shared" {
+			t.Fatalf("Expected every caller to decode the response, caller %d got %q", i, name)
+		}
+	}
+}
+
+func TestClient_CoalesceGETsSkipsMutations(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.CoalesceGETs = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := doRequest(t, client.GodoClient(), http.MethodDelete, "/v2/vpcs/vpc-1"); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("Expected every DELETE to be sent, got %d", n)
+	}
+}
@@ -1,119 +1,906 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"log"
-	"net/url"
-	"strings"
+	"net/http"
+	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/digitalocean/godo"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
 	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
 )
 
 type Config struct {
-	Token             string
+	Token             string `sensitive:"true"`
 	APIEndpoint       string
 	SpacesAPIEndpoint string
-	AccessID          string
-	SecretKey         string
+	AccessID          string `sensitive:"true"`
+	SecretKey         string `sensitive:"true"`
 	RequestsPerSecond float64
 	TerraformVersion  string
-	HTTPRetryMax      int
-	HTTPRetryWaitMax  float64
-	HTTPRetryWaitMin  float64
+	ProviderVersion   string
+
+	// HTTPRetryMax is the maximum number of retries of a request. Zero
+	// disables retries and a negative value selects the default of 4 retries.
+	HTTPRetryMax     int
+	HTTPRetryWaitMax float64
+	HTTPRetryWaitMin float64
+
+	// EnableIdempotencyKeys attaches an Idempotency-Key header to mutating
+	// requests, reused across retries of the same request.
+	EnableIdempotencyKeys bool
+
+	// RetryOnBodySubstrings retries any response whose body contains one of
+	// the given substrings, regardless of its status code. Bodies are
+	// decompressed according to their Content-Encoding before matching.
+	RetryOnBodySubstrings []string
+
+	// TokenInfoURL overrides the OAuth endpoint used to look up the scopes
+	// granted to the token.
+	TokenInfoURL string
+
+	// RetryLogger receives retryablehttp's own diagnostics. It must implement
+	// either retryablehttp.Logger or retryablehttp.LeveledLogger. When nil,
+	// the retry library's logging is left unchanged.
+	RetryLogger interface{}
+
+	// SpacesUploadBytesPerSecond caps the throughput of request bodies sent
+	// by Spaces sessions. Zero leaves uploads unthrottled.
+	SpacesUploadBytesPerSecond int64
+
+	// SpacesUseDualStack sets UseDualStack on the configuration of Spaces
+	// sessions. The SDK applies it to the endpoints it resolves itself, not
+	// to the spaces_endpoint hosts, so in IPv6-preferred environments point
+	// SpacesAPIEndpoint at a host reachable over IPv6 as well.
+	SpacesUseDualStack bool
+
+	// DefaultRateLimitSleep is the pause used after a 429 response that has
+	// neither a RateLimit-Reset nor a Retry-After header. It is capped by
+	// HTTPRetryWaitMax. Zero uses the regular exponential backoff.
+	DefaultRateLimitSleep time.Duration
+
+	// BaseTransport, when set, replaces the transport that sends requests
+	// over the network, e.g. with configtest.FakeThrottleTransport to
+	// exercise the retry logic in memory. An *http.Transport is cloned
+	// before it is configured.
+	BaseTransport http.RoundTripper
+
+	// TransportWrapper, when set, wraps the fully configured transport. It
+	// can be used to plug in instrumentation such as OpenTelemetry's
+	// otelhttp.NewTransport without this package depending on it.
+	TransportWrapper func(http.RoundTripper) http.RoundTripper
+
+	// RespectHourlyBudget paces requests over the remainder of the hourly
+	// rate limit window once the remaining budget runs low.
+	RespectHourlyBudget bool
+
+	// SpacesSessionOptions are additional options used to build Spaces
+	// sessions. The endpoint, region and credentials configured by this
+	// package take precedence over the ones set here.
+	SpacesSessionOptions *session.Options
+
+	// SpacesRequired validates that Spaces credentials are set when the
+	// client is built, rather than when a Spaces session is first requested.
+	SpacesRequired bool
+
+	// ThrottleAlgorithm selects how RequestsPerSecond is enforced. Defaults
+	// to TokenBucket.
+	ThrottleAlgorithm ThrottleAlgorithm
+
+	// RequestsBurst is the number of requests the TokenBucket throttle lets
+	// through at once. Defaults to 1.
+	RequestsBurst int
+
+	// PriorityScheduling dispatches requests waiting on RequestsPerSecond in
+	// order of the Priority attached to their context with WithPriority.
+	PriorityScheduling bool
+
+	// WorkspaceFairQueuing shares RequestsPerSecond between the workspaces
+	// attached to requests with WithWorkspace using weighted fair queuing,
+	// rather than serving requests first come, first served.
+	WorkspaceFairQueuing bool
+
+	// WorkspaceWeights are the relative shares of the rate limit of each
+	// workspace under WorkspaceFairQueuing. Workspaces default to a weight
+	// of 1, including requests without a workspace.
+	WorkspaceWeights map[string]float64
+
+	// CircuitBreakerThreshold is the number of consecutive failed requests
+	// after which requests fail fast with ErrCircuitOpen. Zero disables the
+	// circuit breaker.
+	CircuitBreakerThreshold int
+
+	// CircuitBreakerCooldown is how long the circuit stays open before a
+	// trial request is let through. Defaults to 30 seconds.
+	CircuitBreakerCooldown time.Duration
+
+	// OnCircuitOpen is called when the circuit breaker trips, with the error
+	// of the request that tripped it.
+	OnCircuitOpen func(reason error)
+
+	// OnCircuitClose is called when the circuit breaker recovers.
+	OnCircuitClose func()
+
+	// MaintenanceMaxWait caps how long a request waits for the end of a
+	// maintenance window advertised by a 503 response. Defaults to 5 minutes.
+	MaintenanceMaxWait time.Duration
+
+	// OnMaintenance is called with the expected end of the window whenever a
+	// request waits for a maintenance window.
+	OnMaintenance func(eta time.Time)
+
+	// OverrideBackoff decides how long to wait before a retry. When it
+	// returns true its duration replaces the computed backoff, including
+	// the wait for a rate limit reset; otherwise the default applies.
+	OverrideBackoff func(min, max time.Duration, attempt int, resp *http.Response) (time.Duration, bool)
+
+	// BackoffSchedule sets the wait before each retry: the first retry waits
+	// for the first entry, and so on, the last entry being repeated for any
+	// further retries. It overrides BackoffStrategy, HTTPRetryWaitMin and
+	// HTTPRetryWaitMax, but a 429 whose RateLimit-Reset is further away
+	// still waits for the reset.
+	BackoffSchedule []time.Duration
+
+	// ImmediateRetryAfterExpiredReset retries a 429 without waiting when its
+	// RateLimit-Reset is already in the past, e.g. after a long local pause,
+	// since the rate limit window has reset. By default such responses get
+	// the regular backoff.
+	ImmediateRetryAfterExpiredReset bool
+
+	// DisableResetAwareBackoff ignores the RateLimit-Reset header of 429
+	// responses: rather than waiting for the rate limit window to reset,
+	// they get the same backoff as any other retried response. 429s are
+	// still retried up to HTTPRetryMax times.
+	DisableResetAwareBackoff bool
+
+	// MinRetryInterval is the shortest wait between two attempts of a
+	// request. It raises shorter backoffs, including rate limit waits, but
+	// not durations returned by OverrideBackoff.
+	MinRetryInterval time.Duration
+
+	// Environment labels the deployment environment, e.g. "production", in
+	// the user agent of every request, the retryablehttp log lines, the
+	// expvar stats and the metrics of an EnvironmentMetricsSink.
+	Environment string
+
+	// ServiceRequestsPerSecond limits the rate of requests to individual API
+	// collections, keyed by the first path segment after /v2/, e.g.
+	// "droplets". These apply on top of RequestsPerSecond.
+	ServiceRequestsPerSecond map[string]float64
+
+	// MethodRequestsPerSecond limits the rate of requests per HTTP method,
+	// e.g. "POST". These apply on top of RequestsPerSecond.
+	MethodRequestsPerSecond map[string]float64
+
+	// BackoffStrategy selects the backoff used between retries that are not
+	// governed by rate limit headers. Defaults to ExponentialBackoff.
+	BackoffStrategy BackoffStrategy
+
+	// ProbeAfterLongBackoff sends a lightweight request to /v2/account before
+	// retrying a request that backed off for at least LongBackoffThreshold.
+	// The real request is only retried once the probe succeeds.
+	ProbeAfterLongBackoff bool
+
+	// LongBackoffThreshold is the backoff after which a probe is sent when
+	// ProbeAfterLongBackoff is set. Defaults to 30 seconds.
+	LongBackoffThreshold time.Duration
+
+	// ForceHTTP1 disables HTTP/2, so that requests are spread over several
+	// connections instead of being multiplexed over a single one.
+	ForceHTTP1 bool
+
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+
+	// MinTLSVersion is the lowest TLS version negotiated with the API and
+	// Spaces, e.g. tls.VersionTLS13. Defaults to tls.VersionTLS12, which is
+	// also the lowest version accepted.
+	MinTLSVersion uint16
+
+	// LocalAddresses are local IP addresses that outgoing connections are
+	// bound to in turn, spreading them across several source addresses.
+	LocalAddresses []string
+
+	// AcceptHeader overrides the Accept header sent by godo, which defaults
+	// to "application/json".
+	AcceptHeader string
+
+	// HostHeaderOverride sends API requests with this Host header, e.g. for a
+	// load balancer routing on it, while still connecting to the host of
+	// APIEndpoint.
+	HostHeaderOverride string
+
+	// RequestCosts weights requests against RequestsPerSecond by path
+	// prefix, e.g. {"/v2/kubernetes": 5}. The longest matching prefix wins
+	// and unlisted paths cost 1. With TokenBucket, costs may not exceed
+	// RequestsBurst.
+	RequestCosts map[string]int
+
+	// DefaultResourceTags are added to the tags of the droplets, volumes,
+	// images, load balancers, Kubernetes clusters and databases created
+	// through the client, e.g. "managed-by:terraform".
+	DefaultResourceTags []string
+
+	// SerializeWrites sends mutating requests, such as POST, PUT and DELETE,
+	// one at a time, while reads remain concurrent.
+	SerializeWrites bool
+
+	// DistributedLimiter, when set, is acquired by every request after the
+	// client-side throttles, to coordinate several processes sharing one
+	// token. Requests acquire it with their RequestCosts cost.
+	DistributedLimiter DistributedLimiter
+
+	// TokenSource supplies API tokens. It takes precedence over TokenFile and
+	// Token.
+	TokenSource oauth2.TokenSource
+
+	// OAuthConfig, together with RefreshToken, obtains access tokens from
+	// the token endpoint of OAuthConfig, refreshing them as they expire. It
+	// takes precedence over TokenFile and Token.
+	OAuthConfig *oauth2.Config
+
+	// RefreshToken is the OAuth refresh token used with OAuthConfig.
+	RefreshToken string `sensitive:"true"`
+
+	// DisableTokenReuse asks TokenSource for a token on every request
+	// instead of reusing the last one until it expires. The token source
+	// built from OAuthConfig reuses its tokens regardless, so it does not
+	// refresh on every request.
+	DisableTokenReuse bool
+
+	// FallbackToStaticToken uses Token for requests for which TokenSource
+	// fails, logging a warning, instead of failing them.
+	FallbackToStaticToken bool
+
+	// TokenFile is the path of a file holding the API token. It takes
+	// precedence over Token.
+	TokenFile string
+
+	// OnApproachingLimit is called, at most once per rate limit window, when
+	// the remaining requests advertised by the API drop below
+	// ApproachingLimitThreshold.
+	OnApproachingLimit func(remaining, limit int)
+
+	// ApproachingLimitThreshold is the number of remaining requests below
+	// which OnApproachingLimit is called.
+	ApproachingLimitThreshold int
+
+	// RecordTo is the path of a file to which every API response is
+	// appended, for later use with ReplayFrom. Client fails when it cannot
+	// be opened; later write failures are logged.
+	RecordTo string
+
+	// ReplayFrom is the path of a file written with RecordTo. When set,
+	// responses are served from the recording, matched on method and URL,
+	// instead of being requested from the API.
+	ReplayFrom string
+
+	// MaxURLLength rejects requests whose URL is longer than the given
+	// number of characters. Zero disables the check.
+	MaxURLLength int
+
+	// EnableHTTPTrace logs the DNS, connect, TLS and time-to-first-byte
+	// timings of every attempt.
+	EnableHTTPTrace bool
+
+	// OnHTTPTrace receives the timings of every attempt when EnableHTTPTrace
+	// is set.
+	OnHTTPTrace func(HTTPTiming)
+
+	// SpacesCompressUploads gzips the body of Spaces PutObject requests of
+	// at least SpacesCompressThreshold bytes and stores the object with a
+	// gzip Content-Encoding. Objects that already have a Content-Encoding,
+	// a Content-MD5 or a compressed content type are uploaded as is.
+	SpacesCompressUploads bool
+
+	// SpacesCompressThreshold is the minimum body size compressed when
+	// SpacesCompressUploads is set. Defaults to 64 KiB.
+	SpacesCompressThreshold int64
+
+	// Treat404OnDeleteAsSuccess reports a 404 response to a DELETE request
+	// as a 204, so that deleting a resource that is already gone succeeds.
+	Treat404OnDeleteAsSuccess bool
+
+	// SyntheticLatency delays every request by the given duration, to test
+	// how configurations cope with a slow API. It is meant for testing only.
+	SyntheticLatency time.Duration
+
+	// SyntheticLatencyJitter adds a random delay of up to the given duration
+	// on top of SyntheticLatency.
+	SyntheticLatencyJitter time.Duration
+
+	// QuotaExceededMarker identifies 429 responses reporting an exhausted
+	// quota rather than temporary throttling. Such responses are not retried
+	// and fail with a QuotaExceededError.
+	QuotaExceededMarker string
+
+	// SpacesHTTPRetryMax is the maximum number of retries of Spaces
+	// requests. As with HTTPRetryMax, zero disables retries and a negative
+	// value selects the SDK default of 3 retries.
+	SpacesHTTPRetryMax int
+
+	// SpacesHTTPRetryWaitMin and SpacesHTTPRetryWaitMax bound, in seconds,
+	// the backoff between retries of Spaces requests. Zero uses the SDK
+	// defaults.
+	SpacesHTTPRetryWaitMin float64
+	SpacesHTTPRetryWaitMax float64
+
+	// AllowedHosts restricts the hosts the API client may send requests to,
+	// including after redirects. Entries are host names, optionally with a
+	// port. When empty, all hosts are allowed.
+	AllowedHosts []string
+
+	// ExpvarPrefix publishes the current rate limit, the number of requests
+	// by resource type and the number of retries as an expvar map under the
+	// given name, e.g. for scraping /debug/vars. Empty disables publishing.
+	ExpvarPrefix string
+
+	// ResourceTypes maps API path prefixes, e.g. "/v2/apps", to the resource
+	// type requests are counted under in the published metrics. It extends
+	// and overrides the default mapping.
+	ResourceTypes map[string]string
+
+	// MaxTotalBytes caps the request and response body bytes transferred by
+	// the client, including retries. Once exceeded, requests fail with
+	// ErrTransferLimitExceeded. Zero disables the limit.
+	MaxTotalBytes int64
+
+	// MaxRetryBufferBytes is the largest request or response body buffered
+	// in memory so that the request can be retried. Requests with a larger
+	// body are sent once, and larger response bodies are streamed, so a
+	// truncated one is not retried. Defaults to 4 MiB.
+	MaxRetryBufferBytes int64
+
+	// GlobalDeadline is a time after which the client refuses to make any
+	// request, including retries, failing them with
+	// ErrGlobalDeadlineExceeded. Unlike a context deadline it bounds the whole
+	// lifetime of the client. The zero value disables it.
+	GlobalDeadline time.Time
+
+	// EnableETagCache revalidates GET requests with the ETag of the previous
+	// response, serving the cached body when it has not been modified.
+	EnableETagCache bool
+
+	// ETagCacheStore holds the entries of the ETag cache, e.g. a
+	// FileCacheStore to reuse them across runs. Defaults to a
+	// MemoryCacheStore. Entries include the full response bodies, which may
+	// name resources and hold sensitive values, so a persistent store must
+	// be kept private. Kubeconfigs, database details and registry
+	// credentials are never cached.
+	ETagCacheStore CacheStore
+
+	// CoalesceGETs shares a single request between identical GET requests
+	// made concurrently, such as several resources reading the same VPC.
+	CoalesceGETs bool
+
+	// RateLimitEventBufferSize is the number of recent backoffs kept for
+	// CombinedConfig.RecentRateLimitEvents. Zero disables recording.
+	RateLimitEventBufferSize int
+
+	// RetryOnHeader retries any response carrying the named header, e.g.
+	// "X-Retry", regardless of its status code, unless the header is set to
+	// a false value such as "false" or "0".
+	RetryOnHeader string
+
+	// RetryDNSErrors retries requests that failed on a temporary DNS error.
+	// Defaults to true. Hosts that do not exist are never retried.
+	RetryDNSErrors *bool
+
+	// RetrySafeMethodsOnly only retries GET, HEAD, OPTIONS, PUT and DELETE
+	// requests, so that a POST that may have reached the API is not
+	// repeated, creating a duplicate. Requests of other methods are still
+	// retried when they failed to connect.
+	RetrySafeMethodsOnly bool
+
+	// RetryOn409 retries 409 Conflict responses, which some operations
+	// return while a resource is in a transitional state, e.g. when it is
+	// recreated right after being deleted.
+	RetryOn409 bool
+
+	// TreatErrorBodyOn200AsFailure fails and retries 200 responses whose
+	// body is a DigitalOcean error, as returned by some gateways, rather
+	// than handing the error body to godo as a successful response.
+	TreatErrorBodyOn200AsFailure bool
+
+	// RetryLimitsByStatus caps the retries of responses with the given status
+	// codes, e.g. {429: 10, 500: 2}, in place of HTTPRetryMax, which may be
+	// lower or higher. Responses with other status codes and connection
+	// errors share the HTTPRetryMax limit. Retries are counted per status
+	// code within a request.
+	RetryLimitsByStatus map[int]int
+
+	// MaxRetryOverride caps the Max of a RetryOverride, which may raise the
+	// retries of a request above HTTPRetryMax. Defaults to 10.
+	MaxRetryOverride int
+
+	// Metrics, when set, receives the requests, retries, rate limited
+	// responses and backoffs of the client.
+	Metrics MetricsSink
+
+	// LogSummaryOnClose logs the CombinedConfig.Summary of the client on a
+	// single line when CombinedConfig.Close is called.
+	LogSummaryOnClose bool
+
+	// OnProgress is called every ProgressInterval with the cumulative
+	// activity of the client, until CombinedConfig.Close is called.
+	OnProgress       func(ProgressSnapshot)
+	ProgressInterval time.Duration
 }
 
 type CombinedConfig struct {
-	client                 *godo.Client
-	spacesEndpointTemplate *template.Template
-	accessID               string
-	secretKey              string
+	client                *godo.Client
+	httpClient            *http.Client
+	spacesEndpoints       *spacesEndpointCache
+	accessID              string
+	secretKey             string
+	tokenInfoURL          string
+	spacesUploadLimiter   *rate.Limiter
+	spacesTransports      *sync.Map
+	minTLSVersion         uint16
+	rateLimits            *rateLimitTracker
+	spacesSessionOptions  *session.Options
+	spacesCompressMinSize int64
+	spacesUseDualStack    bool
+	spacesRetryer         request.Retryer
+	requestsPerSecond     float64
+	requestsBurst         int
+	inFlight              *inFlightTracker
+	authMethod            string
+	baseContext           context.Context
+	progress              *progressReporter
+	counters              *progressCounters
+	logSummary            bool
+	closeOnce             *sync.Once
+	backoff               *backoffPolicy
+	limiter               requestLimiter
+	rateLimitEvents       *rateLimitEventLog
+	requestRate           *requestRate
+	baseTransport         *http.Transport
+	abort                 context.CancelFunc
+	hourlyBudget          *hourlyBudgetTransport
+	hostHeaderOverride    string
+	allowedHosts          *allowedHostsTransport
 }
 
 func (c *CombinedConfig) GodoClient() *godo.Client { return c.client }
 
-func (c *CombinedConfig) SpacesClient(region string) (*session.Session, error) {
-	if c.accessID == "" || c.secretKey == "" {
-		err := fmt.Errorf("Spaces credentials not configured")
-		return &session.Session{}, err
+// Close stops the background work started by Config.Client. It is safe to
+// call Close more than once. With LogSummaryOnClose, the first call also logs
+// the run summary. Close does not wait for the requests in flight, see
+// CloseContext.
+func (c *CombinedConfig) Close() error {
+	if c.progress != nil {
+		c.progress.close()
+	}
+	if c.logSummary {
+		c.closeOnce.Do(func() {
+			log.Printf("[INFO] DigitalOcean API run summary: %s", c.Summary())
+		})
 	}
+	return nil
+}
 
-	endpointWriter := strings.Builder{}
-	err := c.spacesEndpointTemplate.Execute(&endpointWriter, map[string]string{
-		"Region": strings.ToLower(region),
-	})
+// WithBaseURL returns a copy of c whose godo client sends requests to
+// baseURL. The copy shares the credentials, transports and rate limit state
+// of c. It fails with HostHeaderOverride, which names the host of
+// APIEndpoint, and when AllowedHosts does not include the host of baseURL.
+func (c *CombinedConfig) WithBaseURL(baseURL string) (*CombinedConfig, error) {
+	apiURL, err := parseEndpoint(baseURL)
 	if err != nil {
-		return &session.Session{}, err
+		return nil, fmt.Errorf("invalid base URL: %s", err)
+	}
+	if c.hostHeaderOverride != "" {
+		return nil, fmt.Errorf("invalid base URL: HostHeaderOverride %q applies to api_endpoint only", c.hostHeaderOverride)
+	}
+	if c.allowedHosts != nil && !c.allowedHosts.allows(apiURL) {
+		return nil, fmt.Errorf("invalid base URL: %w", &hostNotAllowedError{host: apiURL.Host})
 	}
-	endpoint := endpointWriter.String()
 
-	client, err := session.NewSession(&aws.Config{
-		Region:      aws.String("us-east-1"),
-		Credentials: credentials.NewStaticCredentials(c.accessID, c.secretKey, ""),
-		Endpoint:    aws.String(endpoint)},
-	)
-	if err != nil {
-		return &session.Session{}, err
+	godoClient := godo.NewClient(c.httpClient)
+	godoClient.UserAgent = c.client.UserAgent
+	godoClient.BaseURL = apiURL
+
+	clone := *c
+	clone.client = godoClient
+
+	return &clone, nil
+}
+
+// defaultHTTPRetryMax is the number of retries used when HTTPRetryMax is
+// negative.
+const defaultHTTPRetryMax = 4
+
+// retryMax returns the maximum number of retries configured by HTTPRetryMax.
+func (c *Config) retryMax() int {
+	if c.HTTPRetryMax < 0 {
+		return defaultHTTPRetryMax
 	}
+	return c.HTTPRetryMax
+}
+
+// defaultMaxRetryOverride is the ceiling of RetryOverride.Max used when
+// MaxRetryOverride is not set.
+const defaultMaxRetryOverride = 10
 
-	return client, nil
+// maxRetryOverride returns the ceiling of RetryOverride.Max.
+func (c *Config) maxRetryOverride() int {
+	if c.MaxRetryOverride <= 0 {
+		return defaultMaxRetryOverride
+	}
+	return c.MaxRetryOverride
+}
+
+// libraryUserAgent identifies requests made without a TerraformVersion.
+const libraryUserAgent = "terraform-provider-digitalocean"
+
+// userAgent returns the user agent prefix sent with every request.
+func (c *Config) userAgent() string {
+	var userAgent string
+	switch {
+	case c.TerraformVersion != "":
+		userAgent = fmt.Sprintf("Terraform/%s", c.TerraformVersion)
+	case c.ProviderVersion != "":
+		// Outside of Terraform, e.g. when the package is used as a library.
+		userAgent = fmt.Sprintf("%s/%s", libraryUserAgent, c.ProviderVersion)
+	default:
+		userAgent = libraryUserAgent
+	}
+	if c.Environment != "" {
+		userAgent = fmt.Sprintf("%s (environment: %s)", userAgent, c.Environment)
+	}
+	return userAgent
 }
 
 // Client() returns a new client for accessing digital ocean.
 func (c *Config) Client() (*CombinedConfig, error) {
-	tokenSrc := oauth2.StaticTokenSource(&oauth2.Token{
-		AccessToken: c.Token,
-	})
+	return c.ClientContext(context.Background())
+}
 
-	userAgent := fmt.Sprintf("Terraform/%s", c.TerraformVersion)
+// ClientContext returns a new client whose requests are all canceled once ctx
+// is done, in addition to being bound by their own context. Only the
+// cancellation of ctx is propagated, not its values.
+func (c *Config) ClientContext(ctx context.Context) (*CombinedConfig, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSrc, authMethod, err := resolveTokenSource(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := c.userAgent()
 
 	retryableClient := retryablehttp.NewClient()
-	retryableClient.RetryMax = c.HTTPRetryMax
+	retryableClient.RetryMax = c.retryMax()
 	retryableClient.RetryWaitMin = time.Duration(c.HTTPRetryWaitMin * float64(time.Second))
 	retryableClient.RetryWaitMax = time.Duration(c.HTTPRetryWaitMax * float64(time.Second))
+	retryPolicy := newRetryPolicy(c)
+	retryableClient.CheckRetry = retryPolicy.CheckRetry
+	backoff := newBackoffPolicy(c)
+	retryableClient.Backoff = backoff.Backoff
+	retryableClient.ErrorHandler = retryErrorHandler
+	if c.BaseTransport != nil {
+		retryableClient.HTTPClient.Transport = c.BaseTransport
+		if transport, ok := c.BaseTransport.(*http.Transport); ok {
+			retryableClient.HTTPClient.Transport = transport.Clone()
+		}
+	}
+	configureBaseTransport(c, retryableClient.HTTPClient.Transport)
+	baseTransport, _ := retryableClient.HTTPClient.Transport.(*http.Transport)
+
+	switch {
+	case c.ReplayFrom != "":
+		retryableClient.HTTPClient.Transport, err = newReplayTransport(c.ReplayFrom)
+		if err != nil {
+			return nil, err
+		}
+	case c.RecordTo != "":
+		retryableClient.HTTPClient.Transport, err = newRecordingTransport(retryableClient.HTTPClient.Transport, c.RecordTo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rateLimits := &rateLimitTracker{
+		onApproaching:        c.OnApproachingLimit,
+		approachingThreshold: c.ApproachingLimitThreshold,
+	}
+	var stats *expvarStats
+	if c.ExpvarPrefix != "" {
+		stats, err = newExpvarStats(c.ExpvarPrefix)
+		if err != nil {
+			return nil, err
+		}
+		rateLimits.onObserve = stats.observeRate
+		if c.Environment != "" {
+			stats.setEnvironment(c.Environment)
+		}
+	}
+	progress := &progressCounters{}
+	retryableClient.Backoff = progress.countBackoff(retryableClient.Backoff)
+	metrics := c.metricsSink()
+	if metrics != nil {
+		retryableClient.Backoff = reportBackoff(metrics, retryableClient.Backoff)
+	}
+	var rateLimitEvents *rateLimitEventLog
+	if c.RateLimitEventBufferSize > 0 {
+		rateLimitEvents = newRateLimitEventLog(c.RateLimitEventBufferSize)
+		retryableClient.Backoff = rateLimitEvents.recordBackoff(retryableClient.Backoff)
+	}
+	requestRate := newRequestRate(currentRateWindow, currentRateSize)
+	resources := newResourceClassifier(c.ResourceTypes)
+	retryableClient.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+		requestRate.record(time.Now())
+		if state := requestStateFrom(req.Context()); state != nil && attempt > 0 {
+			state.startAttempt()
+		}
+		if stats != nil {
+			if attempt == 0 {
+				stats.requested(resources.classify(req.URL.Path))
+			} else {
+				stats.retried()
+			}
+		}
+		if metrics != nil {
+			if attempt == 0 {
+				metrics.RequestStarted(resources.classify(req.URL.Path))
+			} else {
+				metrics.Retried()
+			}
+		}
+		progress.attempted(attempt)
+	}
+	retryableClient.HTTPClient.Transport = &summaryTransport{
+		base:     retryableClient.HTTPClient.Transport,
+		counters: progress,
+		metrics:  metrics,
+	}
+	if c.MaxTotalBytes > 0 {
+		retryableClient.HTTPClient.Transport = &transferLimitTransport{
+			base: retryableClient.HTTPClient.Transport,
+			max:  c.MaxTotalBytes,
+		}
+	}
+	if !c.GlobalDeadline.IsZero() {
+		retryableClient.HTTPClient.Transport = &globalDeadlineTransport{
+			base:     retryableClient.HTTPClient.Transport,
+			deadline: c.GlobalDeadline,
+		}
+	}
+	var allowedHosts *allowedHostsTransport
+	if len(c.AllowedHosts) > 0 {
+		allowedHosts = newAllowedHostsTransport(retryableClient.HTTPClient.Transport, c.AllowedHosts)
+		retryableClient.HTTPClient.Transport = allowedHosts
+	}
+	retryableClient.HTTPClient.Transport = &bodyBufferTransport{
+		base: retryableClient.HTTPClient.Transport,
+		max:  c.maxRetryBufferBytes(),
+	}
+	if c.EnableHTTPTrace {
+		retryableClient.HTTPClient.Transport = &traceTransport{
+			base:    retryableClient.HTTPClient.Transport,
+			onTrace: c.OnHTTPTrace,
+		}
+	}
+	if c.ProbeAfterLongBackoff {
+		endpoint, err := parseEndpoint(c.APIEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_endpoint: %s", err)
+		}
+		retryableClient.HTTPClient.Transport = &probeTransport{base: retryableClient.HTTPClient.Transport, endpoint: endpoint}
+	}
+	retryableClient.HTTPClient.Transport = &rateLimitTransport{
+		base:    retryableClient.HTTPClient.Transport,
+		tracker: rateLimits,
+	}
+	var hourlyBudget *hourlyBudgetTransport
+	if c.RespectHourlyBudget {
+		hourlyBudget = &hourlyBudgetTransport{
+			base:    retryableClient.HTTPClient.Transport,
+			tracker: rateLimits,
+		}
+		retryableClient.HTTPClient.Transport = hourlyBudget
+	}
+
+	if c.RetryLogger != nil {
+		retryableClient.Logger = c.RetryLogger
+	}
+	if c.Environment != "" {
+		retryableClient.Logger = withEnvironment(retryableClient.Logger, c.Environment)
+	}
 
 	client := retryableClient.StandardClient()
+	client.Transport = &retryBufferTransport{
+		retrying: &retryLimitTransport{
+			base:   client.Transport,
+			client: retryableClient,
+			policy: retryPolicy,
+		},
+		direct: retryableClient.HTTPClient.Transport,
+		max:    c.maxRetryBufferBytes(),
+	}
+	if breaker := newCircuitBreaker(c); breaker != nil {
+		client.Transport = &circuitBreakerTransport{base: client.Transport, breaker: breaker}
+	}
+
+	client.Transport, err = newThrottleTransport(c, client.Transport)
+	if err != nil {
+		return nil, err
+	}
+	var limiter requestLimiter
+	if throttle, ok := client.Transport.(*throttleTransport); ok {
+		limiter = throttle.limiter
+	}
+
+	if c.SerializeWrites {
+		// Writes wait for their turn before the throttles so that they do
+		// not hold tokens while queued.
+		client.Transport = newSerializeWritesTransport(client.Transport)
+	}
+
+	if c.Treat404OnDeleteAsSuccess {
+		client.Transport = &missingDeleteTransport{base: client.Transport}
+	}
+
+	if c.EnableIdempotencyKeys {
+		client.Transport = &idempotencyKeyTransport{base: client.Transport}
+	}
+
+	if len(c.DefaultResourceTags) > 0 {
+		client.Transport = &resourceTagsTransport{base: client.Transport, tags: c.DefaultResourceTags}
+	}
+
+	if c.SyntheticLatency > 0 || c.SyntheticLatencyJitter > 0 {
+		client.Transport = &latencyTransport{
+			base:    client.Transport,
+			latency: c.SyntheticLatency,
+			jitter:  c.SyntheticLatencyJitter,
+		}
+	}
+
+	if c.MaxURLLength > 0 {
+		client.Transport = &urlLengthTransport{base: client.Transport, max: c.MaxURLLength}
+	}
+
+	if c.AcceptHeader != "" {
+		client.Transport = &acceptHeaderTransport{base: client.Transport, accept: c.AcceptHeader}
+	}
+
+	if c.HostHeaderOverride != "" {
+		client.Transport = &hostHeaderTransport{base: client.Transport, host: c.HostHeaderOverride}
+	}
+
+	// Canceling requestCtx aborts the requests still in flight when
+	// CloseContext gives up waiting for them.
+	requestCtx, abort := context.WithCancel(ctx)
+	client.Transport = &baseContextTransport{base: client.Transport, ctx: requestCtx}
+
+	client.Transport = &requestStateTransport{base: client.Transport}
+
+	inFlight := &inFlightTracker{}
+	client.Transport = &inFlightTransport{base: client.Transport, tracker: inFlight}
+
+	if c.EnableETagCache {
+		store := c.ETagCacheStore
+		if store == nil {
+			store = NewMemoryCacheStore()
+		}
+		client.Transport = &etagCacheTransport{base: client.Transport, store: store}
+	}
+
+	if c.CoalesceGETs {
+		client.Transport = &coalesceTransport{base: client.Transport}
+	}
+
+	// The fallback wraps the reused source so that the static token is not
+	// cached in place of the tokens of TokenSource.
+	if !c.DisableTokenReuse {
+		tokenSrc = oauth2.ReuseTokenSource(nil, tokenSrc)
+	}
+	if c.FallbackToStaticToken && authMethod == AuthMethodTokenSource && c.Token != "" {
+		tokenSrc = &fallbackTokenSource{source: tokenSrc, static: staticTokenSource(c.Token)}
+	}
 	client.Transport = &oauth2.Transport{
 		Base:   client.Transport,
-		Source: oauth2.ReuseTokenSource(nil, tokenSrc),
+		Source: tokenSrc,
 	}
 
 	client.Transport = logging.NewTransport("DigitalOcean", client.Transport)
 
-	godoOpts := []godo.ClientOpt{godo.SetUserAgent(userAgent)}
-	if c.RequestsPerSecond > 0.0 {
-		godoOpts = append(godoOpts, godo.SetStaticRateLimit(c.RequestsPerSecond))
+	if c.TransportWrapper != nil {
+		client.Transport = c.TransportWrapper(client.Transport)
 	}
 
-	godoClient, err := godo.New(client, godoOpts...)
+	godoClient, err := godo.New(client, godo.SetUserAgent(userAgent))
 	if err != nil {
+		abort()
 		return nil, err
 	}
 
-	apiURL, err := url.Parse(c.APIEndpoint)
+	apiURL, err := parseEndpoint(c.APIEndpoint)
 	if err != nil {
-		return nil, err
+		abort()
+		return nil, fmt.Errorf("invalid api_endpoint: %s", err)
 	}
 	godoClient.BaseURL = apiURL
 
 	spacesEndpointTemplate, err := template.New("spaces").Parse(c.SpacesAPIEndpoint)
 	if err != nil {
+		abort()
 		return nil, fmt.Errorf("unable to parse spaces_endpoint '%s' as template: %s", c.SpacesAPIEndpoint, err)
 	}
+	spacesEndpoints, err := newSpacesEndpointCache(spacesEndpointTemplate)
+	if err != nil {
+		abort()
+		return nil, err
+	}
+
+	tokenInfoURL := c.TokenInfoURL
+	if tokenInfoURL == "" {
+		tokenInfoURL = defaultTokenInfoURL
+	}
 
-	log.Printf("[INFO] DigitalOcean Client configured for URL: %s", godoClient.BaseURL.String())
+	var spacesCompressMinSize int64
+	if c.SpacesCompressUploads {
+		spacesCompressMinSize = c.SpacesCompressThreshold
+		if spacesCompressMinSize <= 0 {
+			spacesCompressMinSize = defaultSpacesCompressThreshold
+		}
+	}
+
+	var reporter *progressReporter
+	if c.OnProgress != nil && c.ProgressInterval > 0 {
+		reporter = startProgressReporter(progress, c.ProgressInterval, c.OnProgress)
+	}
+
+	if c.Environment != "" {
+		log.Printf("[INFO] DigitalOcean Client configured for URL: %s (environment: %s)", godoClient.BaseURL.String(), c.Environment)
+	} else {
+		log.Printf("[INFO] DigitalOcean Client configured for URL: %s", godoClient.BaseURL.String())
+	}
 
 	return &CombinedConfig{
-		client:                 godoClient,
-		spacesEndpointTemplate: spacesEndpointTemplate,
-		accessID:               c.AccessID,
-		secretKey:              c.SecretKey,
+		client:                godoClient,
+		httpClient:            client,
+		spacesEndpoints:       spacesEndpoints,
+		accessID:              c.AccessID,
+		secretKey:             c.SecretKey,
+		tokenInfoURL:          tokenInfoURL,
+		spacesUploadLimiter:   newUploadLimiter(c.SpacesUploadBytesPerSecond),
+		spacesTransports:      &sync.Map{},
+		minTLSVersion:         c.minTLSVersion(),
+		rateLimits:            rateLimits,
+		spacesSessionOptions:  c.SpacesSessionOptions,
+		spacesCompressMinSize: spacesCompressMinSize,
+		spacesUseDualStack:    c.SpacesUseDualStack,
+		spacesRetryer:         newSpacesRetryer(c),
+		requestsPerSecond:     c.RequestsPerSecond,
+		requestsBurst:         c.RequestsBurst,
+		inFlight:              inFlight,
+		authMethod:            authMethod,
+		baseContext:           ctx,
+		progress:              reporter,
+		counters:              progress,
+		logSummary:            c.LogSummaryOnClose,
+		closeOnce:             &sync.Once{},
+		backoff:               backoff,
+		limiter:               limiter,
+		rateLimitEvents:       rateLimitEvents,
+		requestRate:           requestRate,
+		baseTransport:         baseTransport,
+		abort:                 abort,
+		hourlyBudget:          hourlyBudget,
+		hostHeaderOverride:    c.HostHeaderOverride,
+		allowedHosts:          allowedHosts,
 	}, nil
 }
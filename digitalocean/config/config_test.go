@@ -0,0 +1,264 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+)
+
+// testConfig returns a Config pointed at endpoint with retry waits short
+// enough for unit tests.
+func testConfig(endpoint string) *Config {
+	return &Config{
+		Token:             "test-token",
+		APIEndpoint:       endpoint,
+		SpacesAPIEndpoint: "https://{{.Region}}.digitaloceanspaces.com",
+		TerraformVersion:  "1.0.0",
+		HTTPRetryMax:      3,
+		HTTPRetryWaitMin:  0.001,
+		HTTPRetryWaitMax:  0.01,
+	}
+}
+
+// doRequest issues a request through the configured godo client.
+func doRequest(t *testing.T, client *godo.Client, method, path string) (*godo.Response, error) {
+	t.Helper()
+
+	req, err := client.NewRequest(context.Background(), method, path, nil)
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+
+	return client.Do(context.Background(), req, nil)
+}
+
+func TestClient_BaseURL(t *testing.T) {
+	conf := testConfig("https://mock-api.internal.example.com/")
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := client.GodoClient().BaseURL.String(); got != conf.APIEndpoint {
+		t.Fatalf("Expected %s, got %s", conf.APIEndpoint, got)
+	}
+}
+
+func TestClient_APIEndpointScheme(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Endpoint string
+		Expected string
+		Error    string
+	}{
+		{Name: "valid", Endpoint: "https://api.digitalocean.com", Expected: "https://api.digitalocean.com"},
+		{Name: "uppercase scheme", Endpoint: "HTTPS://api.digitalocean.com", Expected: "https://api.digitalocean.com"},
+		{Name: "missing scheme", Endpoint: "api.digitalocean.com", Error: "has no scheme"},
+		{Name: "host and port", Endpoint: "localhost:8080", Error: `unsupported scheme "localhost"`},
+		{Name: "non-http scheme", Endpoint: "ftp://api.digitalocean.com", Error: `unsupported scheme "ftp"`},
+		{Name: "missing host", Endpoint: "https:///v2", Error: "has no host"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, err := testConfig(tc.Endpoint).Client()
+			if tc.Error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.Error) || !strings.Contains(err.Error(), "invalid api_endpoint") {
+					t.Fatalf("Expected an error containing %q, got %v", tc.Error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := client.GodoClient().BaseURL.String(); got != tc.Expected {
+				t.Fatalf("Expected %s, got %s", tc.Expected, got)
+			}
+		})
+	}
+}
+
+// typeName returns the dynamic type of v, or "<nil>".
+func typeName(v interface{}) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+func TestClient_UserAgentEnvironment(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.Environment = "staging"
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !strings.HasPrefix(userAgent, "Terraform/1.0.0 (environment: staging) ") {
+		t.Fatalf("Expected the environment in the user agent, got %q", userAgent)
+	}
+}
+
+func TestClient_UserAgentWithoutTerraformVersion(t *testing.T) {
+	cases := []struct {
+		Name            string
+		ProviderVersion string
+		Expected        string
+	}{
+		{Name: "provider version", ProviderVersion: "2.30.0", Expected: "terraform-provider-digitalocean/2.30.0 godo/"},
+		{Name: "no version", Expected: "terraform-provider-digitalocean godo/"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var userAgent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userAgent = r.UserAgent()
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.TerraformVersion = ""
+			conf.ProviderVersion = tc.ProviderVersion
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !strings.HasPrefix(userAgent, tc.Expected) || strings.Contains(userAgent, "Terraform/") {
+				t.Fatalf("Expected the user agent to start with %q, got %q", tc.Expected, userAgent)
+			}
+		})
+	}
+}
+
+func TestCombinedConfig_WithBaseURL(t *testing.T) {
+	var authorizations []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	})
+	primary := httptest.NewServer(handler)
+	defer primary.Close()
+	regional := httptest.NewServer(handler)
+	defer regional.Close()
+
+	client, err := testConfig(primary.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	clone, err := client.WithBaseURL(regional.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := clone.GodoClient().BaseURL.String(); got != regional.URL {
+		t.Fatalf("Expected the clone to use %s, got %s", regional.URL, got)
+	}
+	if got := client.GodoClient().BaseURL.String(); got != primary.URL {
+		t.Fatalf("Expected the original to keep %s, got %s", primary.URL, got)
+	}
+	if clone.httpClient != client.httpClient {
+		t.Fatalf("Expected the clone to share the HTTP client")
+	}
+	if clone.GodoClient().UserAgent != client.GodoClient().UserAgent {
+		t.Fatalf("Expected the clone to keep the user agent, got %s", clone.GodoClient().UserAgent)
+	}
+
+	for _, c := range []*CombinedConfig{client, clone} {
+		if _, err := doRequest(t, c.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if len(authorizations) != 2 || authorizations[0] != "Bearer test-token" || authorizations[1] != authorizations[0] {
+		t.Fatalf("Expected both clients to use the same token, got %v", authorizations)
+	}
+}
+
+func TestCombinedConfig_WithBaseURLHostOptions(t *testing.T) {
+	cases := []struct {
+		Name         string
+		HostHeader   string
+		AllowedHosts []string
+		BaseURL      string
+		Error        string
+	}{
+		{
+			Name:       "host header override",
+			HostHeader: "api.internal.example.com",
+			BaseURL:    "https://regional.example.com",
+			Error:      "HostHeaderOverride",
+		},
+		{
+			Name:         "host not allowed",
+			AllowedHosts: []string{"api.digitalocean.com"},
+			BaseURL:      "https://regional.example.com",
+			Error:        `requests to host "regional.example.com" are not allowed`,
+		},
+		{
+			Name:         "host allowed",
+			AllowedHosts: []string{"api.digitalocean.com", "regional.example.com"},
+			BaseURL:      "https://regional.example.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			conf := testConfig("https://api.digitalocean.com")
+			conf.HostHeaderOverride = tc.HostHeader
+			conf.AllowedHosts = tc.AllowedHosts
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			_, err = client.WithBaseURL(tc.BaseURL)
+			if tc.Error == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.Error) {
+				t.Fatalf("Expected an error containing %q, got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestCombinedConfig_WithBaseURLInvalid(t *testing.T) {
+	client, err := testConfig("https://api.digitalocean.com").Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, baseURL := range []string{"api.example.com", "api.example.com:443", "ftp://api.example.com", "https://"} {
+		if _, err := client.WithBaseURL(baseURL); err == nil || !strings.Contains(err.Error(), "invalid base URL") {
+			t.Fatalf("Expected an invalid base URL error for %q, got %v", baseURL, err)
+		}
+	}
+}
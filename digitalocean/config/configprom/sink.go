@@ -0,0 +1,95 @@
+// Package configprom exports the activity of the DigitalOcean API client built
+// by the config package as Prometheus metrics.
+package configprom
+
+import (
+	"time"
+
+	"github.com/digitalocean/terraform-provider-digitalocean/digitalocean/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Sink is a config.MetricsSink updating Prometheus collectors. It can be used
+// as config.Config.Metrics. Every metric is labeled with the environment of
+// the client, empty unless config.Config.Environment is set.
+type Sink struct {
+	requests    *prometheus.CounterVec
+	retries     *prometheus.CounterVec
+	rateLimited *prometheus.CounterVec
+	backoff     *prometheus.HistogramVec
+
+	environment string
+}
+
+// New returns a Sink whose collectors are registered with reg, or with
+// prometheus.DefaultRegisterer when reg is nil:
+//
+//   - digitalocean_api_requests_total, by resource_type and environment
+//   - digitalocean_api_retries_total, by environment
+//   - digitalocean_api_rate_limited_total, by environment
+//   - digitalocean_api_backoff_seconds, by environment
+func New(reg prometheus.Registerer) (*Sink, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	s := &Sink{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "digitalocean",
+			Subsystem: "api",
+			Name:      "requests_total",
+			Help:      "Requests sent to the DigitalOcean API, not counting retries.",
+		}, []string{"resource_type", "environment"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "digitalocean",
+			Subsystem: "api",
+			Name:      "retries_total",
+			Help:      "Retried attempts of requests to the DigitalOcean API.",
+		}, []string{"environment"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "digitalocean",
+			Subsystem: "api",
+			Name:      "rate_limited_total",
+			Help:      "429 Too Many Requests responses of the DigitalOcean API.",
+		}, []string{"environment"}),
+		backoff: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "digitalocean",
+			Subsystem: "api",
+			Name:      "backoff_seconds",
+			Help:      "Waits scheduled before retrying requests to the DigitalOcean API.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+		}, []string{"environment"}),
+	}
+
+	for _, collector := range []prometheus.Collector{s.requests, s.retries, s.rateLimited, s.backoff} {
+		if err := reg.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// WithEnvironment returns a Sink updating the same collectors with the given
+// environment label. It satisfies config.EnvironmentMetricsSink.
+func (s *Sink) WithEnvironment(environment string) config.MetricsSink {
+	labeled := *s
+	labeled.environment = environment
+	return &labeled
+}
+
+func (s *Sink) RequestStarted(resourceType string) {
+	s.requests.WithLabelValues(resourceType, s.environment).Inc()
+}
+
+func (s *Sink) Retried() {
+	s.retries.WithLabelValues(s.environment).Inc()
+}
+
+func (s *Sink) RateLimited() {
+	s.rateLimited.WithLabelValues(s.environment).Inc()
+}
+
+func (s *Sink) BackedOff(sleep time.Duration) {
+	s.backoff.WithLabelValues(s.environment).Observe(sleep.Seconds())
+}
@@ -0,0 +1,142 @@
+package configprom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/digitalocean/terraform-provider-digitalocean/digitalocean/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// The sink must satisfy the interface expected by config.Config.Metrics.
+var _ config.EnvironmentMetricsSink = (*Sink)(nil)
+
+func TestSink(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	sink, err := New(reg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	conf := &config.Config{
+		Token:             "test-token",
+		APIEndpoint:       server.URL,
+		SpacesAPIEndpoint: "https://{{.Region}}.digitaloceanspaces.com",
+		TerraformVersion:  "1.0.0",
+		HTTPRetryMax:      3,
+		HTTPRetryWaitMin:  0.001,
+		HTTPRetryWaitMax:  0.01,
+		Metrics:           sink,
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	godoClient := client.GodoClient()
+
+	for _, path := range []string{"/v2/droplets", "/v2/droplets", "/v2/volumes"} {
+		req, err := godoClient.NewRequest(context.Background(), http.MethodGet, path, nil)
+		if err != nil {
+			t.Fatalf("unable to build request: %s", err)
+		}
+		if _, err := godoClient.Do(context.Background(), req, nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	counters := []struct {
+		Name      string
+		Collector prometheus.Collector
+		Expected  float64
+	}{
+		{Name: "droplet requests", Collector: sink.requests.WithLabelValues("droplets", ""), Expected: 2},
+		{Name: "volume requests", Collector: sink.requests.WithLabelValues("volumes", ""), Expected: 1},
+		{Name: "retries", Collector: sink.retries.WithLabelValues(""), Expected: 2},
+		{Name: "rate limited", Collector: sink.rateLimited.WithLabelValues(""), Expected: 1},
+	}
+	for _, c := range counters {
+		if got := testutil.ToFloat64(c.Collector); got != c.Expected {
+			t.Fatalf("Expected %s to be %g, got %g", c.Name, c.Expected, got)
+		}
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "digitalocean_api_backoff_seconds" {
+			if n := family.GetMetric()[0].GetHistogram().GetSampleCount(); n != 2 {
+				t.Fatalf("Expected 2 backoffs to be observed, got %d", n)
+			}
+			return
+		}
+	}
+	t.Fatalf("Expected the backoff histogram to be registered")
+}
+
+func TestSink_Environment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	sink, err := New(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	conf := &config.Config{
+		Token:             "test-token",
+		APIEndpoint:       server.URL,
+		SpacesAPIEndpoint: "https://{{.Region}}.digitaloceanspaces.com",
+		TerraformVersion:  "1.0.0",
+		Environment:       "staging",
+		Metrics:           sink,
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	godoClient := client.GodoClient()
+
+	req, err := godoClient.NewRequest(context.Background(), http.MethodGet, "/v2/droplets", nil)
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+	if _, err := godoClient.Do(context.Background(), req, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := testutil.ToFloat64(sink.requests.WithLabelValues("droplets", "staging")); got != 1 {
+		t.Fatalf("Expected 1 request labeled with the environment, got %g", got)
+	}
+	if got := testutil.ToFloat64(sink.requests.WithLabelValues("droplets", "")); got != 0 {
+		t.Fatalf("Expected no unlabeled requests, got %g", got)
+	}
+}
+
+func TestNew_DuplicateRegistration(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := New(reg); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := New(reg); err == nil {
+		t.Fatalf("Expected registering the collectors twice to fail")
+	}
+}
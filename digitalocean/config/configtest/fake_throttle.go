@@ -0,0 +1,57 @@
+package configtest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FakeThrottleTransport returns a transport answering every request with the
+// next status code of sequence, starting over once it is exhausted, without
+// any network access. It can be used as config.Config.BaseTransport to
+// exercise retries and backoff in memory. An empty sequence answers every
+// request with 200 OK.
+func FakeThrottleTransport(sequence []int) http.RoundTripper {
+	return &fakeThrottleTransport{sequence: append([]int(nil), sequence...)}
+}
+
+type fakeThrottleTransport struct {
+	mu       sync.Mutex
+	sequence []int
+	next     int
+}
+
+func (t *fakeThrottleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	status := http.StatusOK
+	t.mu.Lock()
+	if len(t.sequence) > 0 {
+		status = t.sequence[t.next]
+		t.next = (t.next + 1) % len(t.sequence)
+	}
+	t.mu.Unlock()
+
+	body := "{}"
+	if status >= http.StatusBadRequest {
+		id := strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+		body = fmt.Sprintf(`{"id":%q,"message":%q}`, id, http.StatusText(status))
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
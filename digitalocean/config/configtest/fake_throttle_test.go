@@ -0,0 +1,68 @@
+package configtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/digitalocean/terraform-provider-digitalocean/digitalocean/config"
+)
+
+func TestFakeThrottleTransport_Retries(t *testing.T) {
+	conf := &config.Config{
+		Token:             "test-token",
+		APIEndpoint:       "https://api.digitalocean.com",
+		SpacesAPIEndpoint: "https://{{.Region}}.digitaloceanspaces.com",
+		TerraformVersion:  "1.0.0",
+		HTTPRetryMax:      3,
+		HTTPRetryWaitMin:  0.001,
+		HTTPRetryWaitMax:  0.01,
+		BaseTransport:     FakeThrottleTransport([]int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}),
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	godoClient := client.GodoClient()
+
+	// The sequence starts over for the second request.
+	for i := 0; i < 2; i++ {
+		req, err := godoClient.NewRequest(context.Background(), http.MethodGet, "/v2/account", nil)
+		if err != nil {
+			t.Fatalf("unable to build request: %s", err)
+		}
+		resp, err := godoClient.Do(context.Background(), req, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		timeline := config.RetryTimeline(resp.Response)
+		if len(timeline) != 3 {
+			t.Fatalf("Expected 2 retries, got %d attempts", len(timeline))
+		}
+		for j, expected := range []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK} {
+			if timeline[j].StatusCode != expected {
+				t.Fatalf("Expected attempt %d to return %d, got %d", j, expected, timeline[j].StatusCode)
+			}
+		}
+	}
+}
+
+func TestFakeThrottleTransport_Cycles(t *testing.T) {
+	transport := FakeThrottleTransport([]int{http.StatusTooManyRequests, http.StatusOK})
+
+	expected := []int{http.StatusTooManyRequests, http.StatusOK, http.StatusTooManyRequests, http.StatusOK}
+	for i, status := range expected {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.digitalocean.com/v2/account", nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != status {
+			t.Fatalf("Expected response %d to be %d, got %d", i, status, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package configtest provides helpers for testing code that uses the
+// DigitalOcean API client built by the config package.
+package configtest
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// RateRecorder is a transport that records when each request completes. Its
+// Wrap method can be used as config.Config.TransportWrapper.
+type RateRecorder struct {
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	times []time.Time
+}
+
+// NewRateRecorder returns a RateRecorder sending requests through base, or
+// http.DefaultTransport when base is nil.
+func NewRateRecorder(base http.RoundTripper) *RateRecorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RateRecorder{base: base}
+}
+
+// Wrap sets base as the transport of r and returns r.
+func (r *RateRecorder) Wrap(base http.RoundTripper) http.RoundTripper {
+	r.base = base
+	return r
+}
+
+func (r *RateRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+
+	r.mu.Lock()
+	r.times = append(r.times, time.Now())
+	r.mu.Unlock()
+
+	return resp, err
+}
+
+// Times returns when each recorded request completed, in order.
+func (r *RateRecorder) Times() []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	times := make([]time.Time, len(r.times))
+	copy(times, r.times)
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// AssertMaxRate fails t if any one-second window starting at a recorded
+// request holds more than requestsPerSecond requests. One extra request per
+// window is tolerated, since requests are timed when they complete rather
+// than when they are sent.
+func (r *RateRecorder) AssertMaxRate(t testing.TB, requestsPerSecond float64) {
+	t.Helper()
+
+	allowed := int(math.Ceil(requestsPerSecond)) + 1
+	times := r.Times()
+
+	end := 0
+	for start := range times {
+		for end < len(times) && times[end].Sub(times[start]) < time.Second {
+			end++
+		}
+		if count := end - start; count > allowed {
+			t.Errorf("%d requests within one second starting at %s, exceeding %g requests per second",
+				count, times[start].Format(time.RFC3339Nano), requestsPerSecond)
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package configtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/terraform-provider-digitalocean/digitalocean/config"
+)
+
+// recordingT captures the failures reported to it.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func sendRequests(t *testing.T, requestsPerSecond float64, count int) *RateRecorder {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	recorder := NewRateRecorder(nil)
+	conf := &config.Config{
+		Token:             "test-token",
+		APIEndpoint:       server.URL,
+		SpacesAPIEndpoint: "https://{{.Region}}.digitaloceanspaces.com",
+		TerraformVersion:  "1.0.0",
+		RequestsPerSecond: requestsPerSecond,
+		TransportWrapper:  recorder.Wrap,
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	godoClient := client.GodoClient()
+
+	for i := 0; i < count; i++ {
+		req, err := godoClient.NewRequest(context.Background(), http.MethodGet, "/v2/account", nil)
+		if err != nil {
+			t.Fatalf("unable to build request: %s", err)
+		}
+		if _, err := godoClient.Do(context.Background(), req, nil); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	return recorder
+}
+
+func TestRateRecorder_WithinRate(t *testing.T) {
+	recorder := sendRequests(t, 100, 110)
+
+	if n := len(recorder.Times()); n != 110 {
+		t.Fatalf("Expected 110 recorded requests, got %d", n)
+	}
+
+	recorder.AssertMaxRate(t, 100)
+}
+
+func TestRateRecorder_CatchesViolation(t *testing.T) {
+	recorder := sendRequests(t, 0, 20)
+
+	rt := &recordingT{}
+	recorder.AssertMaxRate(rt, 5)
+
+	if len(rt.errors) != 1 {
+		t.Fatalf("Expected the unthrottled requests to be reported, got %v", rt.errors)
+	}
+}
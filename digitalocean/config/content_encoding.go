@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxDecodedBodySize bounds the size of a decompressed body, so that a small
+// compressed error body cannot expand without limit while it is inspected.
+const maxDecodedBodySize = 1 << 20
+
+// inspectBody returns the body of resp for matching or parsing, decompressed
+// according to its Content-Encoding header. The body of resp itself is left
+// readable and unchanged, still encoded, for downstream readers. When the
+// body cannot be decompressed, it is returned as received.
+func inspectBody(resp *http.Response) ([]byte, error) {
+	body, err := peekBody(resp)
+	if err != nil || len(body) == 0 {
+		return body, err
+	}
+
+	decoded, err := decodeBody(body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return body, nil
+	}
+	return decoded, nil
+}
+
+// decodeBody reverses the codings listed in encoding, applied in the order
+// they are listed. Identity and unknown codings are left as is.
+func decodeBody(body []byte, encoding string) ([]byte, error) {
+	codings := strings.Split(encoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		var r io.Reader
+		var err error
+
+		switch coding := strings.ToLower(strings.TrimSpace(codings[i])); coding {
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(bytes.NewReader(body))
+		case "deflate":
+			// Deflate is meant to be zlib-wrapped, but some servers send a raw
+			// deflate stream instead.
+			r, err = zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				r, err = flate.NewReader(bytes.NewReader(body)), nil
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode %s body: %w", codings[i], err)
+		}
+
+		decoded, err := io.ReadAll(io.LimitReader(r, maxDecodedBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode %s body: %w", codings[i], err)
+		}
+		body = decoded
+	}
+	return body, nil
+}
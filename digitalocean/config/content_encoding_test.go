@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func compress(t *testing.T, encoding string, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	io.WriteString(w, body)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	const body = `{"id":"too_many_requests","message":"API rate limit exceeded."}`
+
+	cases := []struct {
+		Name      string
+		Encoding  string
+		Body      []byte
+		ExpectErr bool
+	}{
+		{Name: "identity", Body: []byte(body)},
+		{Name: "gzip", Encoding: "gzip", Body: compress(t, "gzip", body)},
+		{Name: "x-gzip", Encoding: "X-Gzip", Body: compress(t, "gzip", body)},
+		{Name: "deflate", Encoding: "deflate", Body: compress(t, "zlib", body)},
+		{Name: "raw deflate", Encoding: "deflate", Body: compress(t, "flate", body)},
+		{Name: "unknown coding", Encoding: "identity, br", Body: []byte(body)},
+		{Name: "corrupt gzip", Encoding: "gzip", Body: []byte(body), ExpectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			decoded, err := decodeBody(tc.Body, tc.Encoding)
+			if tc.ExpectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %q", decoded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+			if string(decoded) != body {
+				t.Fatalf("Expected %q, got %q", body, decoded)
+			}
+		})
+	}
+}
+
+func TestParseAPIError_GzipEncoded(t *testing.T) {
+	compressed := compress(t, "gzip", `{"id":"service_unavailable","message":"Server is overloaded."}`)
+	resp := testResponse(http.StatusServiceUnavailable, map[string]string{"Content-Encoding": "gzip"})
+	resp.Body = io.NopCloser(bytes.NewReader(compressed))
+
+	apiErr := parseAPIError(resp)
+	if apiErr == nil || apiErr.ID != "service_unavailable" {
+		t.Fatalf("Expected the gzip encoded error to be parsed, got %+v", apiErr)
+	}
+
+	// Downstream readers still get the body as received.
+	body, _ := io.ReadAll(resp.Body)
+	if !bytes.Equal(body, compressed) {
+		t.Fatalf("Expected the body to remain encoded, got %q", body)
+	}
+}
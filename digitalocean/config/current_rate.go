@@ -0,0 +1,81 @@
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// currentRateWindow is the window over which CurrentRate is computed.
+	currentRateWindow = 10 * time.Second
+
+	// currentRateSize bounds the timestamps kept for CurrentRate. Above
+	// currentRateSize requests per window, the rate is computed over the
+	// span of the kept timestamps instead.
+	currentRateSize = 1024
+)
+
+// requestRate keeps the times of the most recent attempts in a ring buffer.
+type requestRate struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	times []time.Time
+	next  int
+	full  bool
+}
+
+func newRequestRate(window time.Duration, size int) *requestRate {
+	return &requestRate{window: window, times: make([]time.Time, size)}
+}
+
+// record adds an attempt made at t, dropping the oldest one once the buffer
+// is full. Attempts are expected to be recorded in the order they are made.
+func (r *requestRate) record(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.times[r.next] = t
+	r.next = (r.next + 1) % len(r.times)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// rate returns the attempts per second over the window ending at now.
+func (r *requestRate) rate(now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.next
+	if r.full {
+		kept = len(r.times)
+	}
+
+	since := now.Add(-r.window)
+	count := 0
+	var oldest time.Time
+	for count < kept {
+		// Walk back from the newest attempt.
+		t := r.times[(r.next-1-count+len(r.times))%len(r.times)]
+		if t.Before(since) {
+			break
+		}
+		oldest = t
+		count++
+	}
+
+	if count == len(r.times) {
+		// The window holds more attempts than were kept.
+		if span := now.Sub(oldest); span > 0 {
+			return float64(count) / span.Seconds()
+		}
+	}
+	return float64(count) / r.window.Seconds()
+}
+
+// CurrentRate returns the requests per second sent by the client over the
+// last 10 seconds, counting each retry as a request.
+func (c *CombinedConfig) CurrentRate() float64 {
+	return c.requestRate.rate(time.Now())
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestRate(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	cases := []struct {
+		Name     string
+		Size     int
+		Offsets  []time.Duration
+		Now      time.Duration
+		Expected float64
+	}{
+		{Name: "no requests", Size: 8, Now: time.Second},
+		{
+			Name:     "within window",
+			Size:     8,
+			Offsets:  []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+			Now:      5 * time.Second,
+			Expected: 0.5,
+		},
+		{
+			Name:     "older requests ignored",
+			Size:     8,
+			Offsets:  []time.Duration{0, time.Second, 12 * time.Second, 13 * time.Second},
+			Now:      14 * time.Second,
+			Expected: 0.2,
+		},
+		{
+			Name:     "window exceeds buffer",
+			Size:     4,
+			Offsets:  []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond},
+			Now:      600 * time.Millisecond,
+			Expected: 10,
+		},
+		{
+			Name:     "wrapped buffer",
+			Size:     4,
+			Offsets:  []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second, 11 * time.Second, 12 * time.Second},
+			Now:      13 * time.Second,
+			Expected: 0.3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := newRequestRate(10*time.Second, tc.Size)
+			for _, offset := range tc.Offsets {
+				r.record(start.Add(offset))
+			}
+
+			if got := r.rate(start.Add(tc.Now)); math.Abs(got-tc.Expected) > 1e-9 {
+				t.Fatalf("Expected a rate of %g, got %g", tc.Expected, got)
+			}
+		})
+	}
+}
+
+func TestClient_CurrentRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if rate := client.CurrentRate(); rate != 0 {
+		t.Fatalf("Expected a rate of 0, got %g", rate)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if rate := client.CurrentRate(); rate != 0.5 {
+		t.Fatalf("Expected a rate of 0.5, got %g", rate)
+	}
+}
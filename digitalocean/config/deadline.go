@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrGlobalDeadlineExceeded is returned for requests attempted after
+// GlobalDeadline. Such requests are never retried.
+var ErrGlobalDeadlineExceeded = errors.New("global deadline exceeded")
+
+// globalDeadlineTransport rejects every attempt made after deadline.
+type globalDeadlineTransport struct {
+	base     http.RoundTripper
+	deadline time.Time
+}
+
+func (t *globalDeadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if now := time.Now(); !now.Before(t.deadline) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s %s attempted %s after the deadline of %s",
+			ErrGlobalDeadlineExceeded, req.Method, req.URL.Path,
+			now.Sub(t.deadline).Round(time.Millisecond), t.deadline.Format(time.RFC3339))
+	}
+
+	return t.base.RoundTrip(req)
+}
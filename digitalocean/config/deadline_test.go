@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_GlobalDeadline(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Deadline         time.Time
+		ExpectErr        bool
+		ExpectedRequests int32
+	}{
+		{Name: "past", Deadline: time.Now().Add(-time.Minute), ExpectErr: true},
+		{Name: "future", Deadline: time.Now().Add(time.Hour), ExpectedRequests: 1},
+		{Name: "disabled", ExpectedRequests: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.GlobalDeadline = tc.Deadline
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+			if tc.ExpectErr {
+				if !errors.Is(err, ErrGlobalDeadlineExceeded) {
+					t.Fatalf("Expected ErrGlobalDeadlineExceeded, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			if n := atomic.LoadInt32(&requests); n != tc.ExpectedRequests {
+				t.Fatalf("Expected %d requests, got %d", tc.ExpectedRequests, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"io"
+	"net/http"
+)
+
+// missingDeleteTransport turns a 404 response to a DELETE request into a 204,
+// treating the deletion of a resource that is already gone as a success.
+type missingDeleteTransport struct {
+	base http.RoundTripper
+}
+
+func (t *missingDeleteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method != http.MethodDelete || resp.StatusCode != http.StatusNotFound {
+		return resp, err
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	resp.StatusCode = http.StatusNoContent
+	resp.Status = "204 No Content"
+	resp.Body = http.NoBody
+	resp.ContentLength = 0
+	resp.Header.Del("Content-Type")
+	resp.Header.Del("Content-Length")
+
+	return resp, nil
+}
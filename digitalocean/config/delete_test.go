@@ -0,0 +1,67 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Treat404OnDeleteAsSuccess(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Enabled   bool
+		Method    string
+		Expected  int
+		ExpectErr bool
+	}{
+		{
+			Name:      "disabled",
+			Method:    http.MethodDelete,
+			Expected:  http.StatusNotFound,
+			ExpectErr: true,
+		},
+		{
+			Name:     "DELETE",
+			Enabled:  true,
+			Method:   http.MethodDelete,
+			Expected: http.StatusNoContent,
+		},
+		{
+			Name:      "GET",
+			Enabled:   true,
+			Method:    http.MethodGet,
+			Expected:  http.StatusNotFound,
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"id": "not_found", "message": "The resource you requested could not be found."}`))
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.Treat404OnDeleteAsSuccess = tc.Enabled
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			resp, err := doRequest(t, client.GodoClient(), tc.Method, "/v2/droplets/123")
+			if tc.ExpectErr && err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if !tc.ExpectErr && err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+			if resp.StatusCode != tc.Expected {
+				t.Fatalf("Expected status %d, got %d", tc.Expected, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/go-multierror"
+)
+
+// diagnosticSpacesRegion is the region whose Spaces endpoint is checked by
+// Diagnose.
+const diagnosticSpacesRegion = "nyc3"
+
+// Names of the steps of a DiagnosticReport.
+const (
+	DiagnosticStepDNS    = "dns"
+	DiagnosticStepTLS    = "tls"
+	DiagnosticStepAPI    = "api"
+	DiagnosticStepSpaces = "spaces"
+)
+
+// DiagnosticStep is the outcome of one step of Diagnose.
+type DiagnosticStep struct {
+	// Name is one of the DiagnosticStep constants.
+	Name string
+
+	// Err is nil when the step succeeded.
+	Err error
+
+	// Skipped is set when the step does not apply to the configuration,
+	// e.g. the TLS step of an http:// API endpoint.
+	Skipped bool
+
+	// Latency is how long the step took.
+	Latency time.Duration
+}
+
+// DiagnosticReport lists the steps run by Diagnose in order.
+type DiagnosticReport struct {
+	Steps []DiagnosticStep
+}
+
+// OK reports whether no step failed.
+func (r *DiagnosticReport) OK() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report one step per line, e.g. for a support ticket.
+func (r *DiagnosticReport) String() string {
+	var b strings.Builder
+	for _, step := range r.Steps {
+		switch {
+		case step.Skipped:
+			fmt.Fprintf(&b, "%s: skipped\n", step.Name)
+		case step.Err != nil:
+			fmt.Fprintf(&b, "%s: failed after %s: %s\n", step.Name, step.Latency, step.Err)
+		default:
+			fmt.Fprintf(&b, "%s: ok in %s\n", step.Name, step.Latency)
+		}
+	}
+	return b.String()
+}
+
+// Diagnose checks the connectivity of the client end to end: resolving the
+// API host, a TLS handshake with it, an authenticated API call and, when
+// Spaces credentials are configured, listing the buckets of a Spaces region.
+// Every step runs even if an earlier one failed. The API call goes through
+// the client, so it is subject to the configured rate limits and retries.
+//
+// The report is always returned. The error combines the errors of the steps
+// that failed, if any.
+func (c *CombinedConfig) Diagnose(ctx context.Context) (*DiagnosticReport, error) {
+	host := c.client.BaseURL.Hostname()
+	port := c.client.BaseURL.Port()
+	useTLS := strings.EqualFold(c.client.BaseURL.Scheme, "https")
+	if port == "" {
+		port = "80"
+		if useTLS {
+			port = "443"
+		}
+	}
+
+	report := &DiagnosticReport{}
+	run := func(name string, step func() error) {
+		start := time.Now()
+		err := step()
+		report.Steps = append(report.Steps, DiagnosticStep{Name: name, Err: err, Latency: time.Since(start)})
+	}
+
+	run(DiagnosticStepDNS, func() error {
+		_, err := net.DefaultResolver.LookupHost(ctx, host)
+		return err
+	})
+
+	if useTLS {
+		run(DiagnosticStepTLS, func() error {
+			return c.diagnoseTLS(ctx, host, port)
+		})
+	} else {
+		report.Steps = append(report.Steps, DiagnosticStep{Name: DiagnosticStepTLS, Skipped: true})
+	}
+
+	run(DiagnosticStepAPI, func() error {
+		_, _, err := c.client.Account.Get(ctx)
+		return err
+	})
+
+	if c.accessID != "" && c.secretKey != "" {
+		run(DiagnosticStepSpaces, func() error {
+			sess, err := c.SpacesClient(diagnosticSpacesRegion)
+			if err != nil {
+				return err
+			}
+			_, err = s3.New(sess).ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+			return err
+		})
+	}
+
+	var result *multierror.Error
+	for _, step := range report.Steps {
+		if step.Err != nil {
+			result = multierror.Append(result, fmt.Errorf("%s: %w", step.Name, step.Err))
+		}
+	}
+
+	return report, result.ErrorOrNil()
+}
+
+// diagnoseTLS completes a TLS handshake with host using the dialer and TLS
+// settings of the client's transport.
+func (c *CombinedConfig) diagnoseTLS(ctx context.Context, host, port string) error {
+	dial := (&net.Dialer{}).DialContext
+	config := withMinTLSVersion(nil, c.minTLSVersion)
+	if c.baseTransport != nil {
+		if c.baseTransport.DialContext != nil {
+			dial = c.baseTransport.DialContext
+		}
+		config = withMinTLSVersion(c.baseTransport.TLSClientConfig, c.minTLSVersion)
+	}
+	config.ServerName = host
+
+	conn, err := dial(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return tls.Client(conn, config).HandshakeContext(ctx)
+}
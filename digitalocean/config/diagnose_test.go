@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCombinedConfig_Diagnose(t *testing.T) {
+	cases := []struct {
+		Name         string
+		TrustAPI     bool
+		APIStatus    int
+		SpacesStatus int
+		Failed       []string
+	}{
+		{
+			Name:         "healthy",
+			TrustAPI:     true,
+			APIStatus:    http.StatusOK,
+			SpacesStatus: http.StatusOK,
+		},
+		{
+			Name:         "untrusted certificate and unauthorized token",
+			APIStatus:    http.StatusUnauthorized,
+			SpacesStatus: http.StatusOK,
+			Failed:       []string{DiagnosticStepTLS, DiagnosticStepAPI},
+		},
+		{
+			Name:         "spaces access denied",
+			TrustAPI:     true,
+			APIStatus:    http.StatusOK,
+			SpacesStatus: http.StatusForbidden,
+			Failed:       []string{DiagnosticStepSpaces},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			api := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.APIStatus)
+				if tc.APIStatus == http.StatusOK {
+					w.Write([]byte(`{"account":{"email":"user@example.com","status":"active"}}`))
+					return
+				}
+				w.Write([]byte(`{"id":"unauthorized","message":"Unable to authenticate you"}`))
+			}))
+			defer api.Close()
+
+			spaces := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(tc.SpacesStatus)
+				if tc.SpacesStatus == http.StatusOK {
+					w.Write([]byte(`<ListAllMyBucketsResult><Buckets></Buckets></ListAllMyBucketsResult>`))
+					return
+				}
+				w.Write([]byte(`<Error><Code>AccessDenied</Code></Error>`))
+			}))
+			defer spaces.Close()
+
+			c := testConfig(api.URL)
+			c.AccessID = "access-id"
+			c.SecretKey = "secret-key"
+			c.SpacesAPIEndpoint = spaces.URL
+			if tc.TrustAPI {
+				c.BaseTransport = api.Client().Transport
+			}
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			report, err := client.Diagnose(context.Background())
+			if report == nil {
+				t.Fatalf("Expected a report")
+			}
+
+			expected := []string{DiagnosticStepDNS, DiagnosticStepTLS, DiagnosticStepAPI, DiagnosticStepSpaces}
+			if len(report.Steps) != len(expected) {
+				t.Fatalf("Expected %d steps, got %s", len(expected), report)
+			}
+
+			failed := map[string]bool{}
+			for _, name := range tc.Failed {
+				failed[name] = true
+			}
+			for i, step := range report.Steps {
+				if step.Name != expected[i] {
+					t.Fatalf("Expected step %d to be %s, got %s", i, expected[i], step.Name)
+				}
+				if failed[step.Name] != (step.Err != nil) {
+					t.Fatalf("Expected step %s to fail: %t, got %v", step.Name, failed[step.Name], step.Err)
+				}
+				if step.Latency <= 0 {
+					t.Fatalf("Expected the latency of step %s to be recorded", step.Name)
+				}
+			}
+
+			if report.OK() != (len(tc.Failed) == 0) {
+				t.Fatalf("Expected OK to be %t, got report %s", len(tc.Failed) == 0, report)
+			}
+			if len(tc.Failed) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %s", err)
+				}
+				return
+			}
+			for _, name := range tc.Failed {
+				if err == nil || !strings.Contains(err.Error(), name+": ") {
+					t.Fatalf("Expected the error to name step %s, got %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCombinedConfig_DiagnoseSkipsTLSOverHTTP(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"account":{}}`))
+	}))
+	defer api.Close()
+
+	client, err := testConfig(api.URL).Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	report, err := client.Diagnose(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	// Spaces is not checked without credentials.
+	if len(report.Steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %s", report)
+	}
+	if tlsStep := report.Steps[1]; !tlsStep.Skipped || tlsStep.Err != nil {
+		t.Fatalf("Expected the TLS step to be skipped, got %+v", tlsStep)
+	}
+}
+
+func TestCombinedConfig_DiagnoseRespectsContext(t *testing.T) {
+	client, err := testConfig("https://api.digitalocean.com").Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := client.Diagnose(ctx)
+	if err == nil || report.OK() {
+		t.Fatalf("Expected the steps to fail, got %s", report)
+	}
+	for _, step := range report.Steps {
+		if step.Err == nil {
+			t.Fatalf("Expected step %s to fail with a canceled context", step.Name)
+		}
+	}
+}
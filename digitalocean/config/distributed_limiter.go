@@ -0,0 +1,22 @@
+package config
+
+import "context"
+
+// DistributedLimiter coordinates the requests of several processes sharing
+// one token, e.g. with a semaphore or rate limiter held in Redis or etcd.
+type DistributedLimiter interface {
+	// Acquire blocks until a request of the given cost may be sent, or
+	// returns an error, e.g. when ctx is done, failing the request.
+	Acquire(ctx context.Context, cost int) error
+
+	// Release is called once the request admitted by a successful Acquire
+	// has completed, including its retries.
+	Release()
+}
+
+// NoopDistributedLimiter admits every request immediately.
+type NoopDistributedLimiter struct{}
+
+func (NoopDistributedLimiter) Acquire(ctx context.Context, cost int) error { return nil }
+
+func (NoopDistributedLimiter) Release() {}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeDistributedLimiter records the calls made to it.
+type fakeDistributedLimiter struct {
+	err error
+
+	mu       sync.Mutex
+	costs    []int
+	held     int
+	releases int
+}
+
+func (l *fakeDistributedLimiter) Acquire(ctx context.Context, cost int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.costs = append(l.costs, cost)
+	if l.err != nil {
+		return l.err
+	}
+	l.held++
+	return nil
+}
+
+func (l *fakeDistributedLimiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.held--
+	l.releases++
+}
+
+func TestClient_DistributedLimiter(t *testing.T) {
+	limiter := &fakeDistributedLimiter{}
+	var held int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter.mu.Lock()
+		held = limiter.held
+		limiter.mu.Unlock()
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.DistributedLimiter = limiter
+	c.RequestCosts = map[string]int{"/v2/kubernetes": 5}
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	for _, path := range []string{"/v2/account", "/v2/kubernetes/clusters"} {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, path); err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if held != 1 {
+			t.Fatalf("Expected the limiter to be held during the request, got %d", held)
+		}
+	}
+
+	if len(limiter.costs) != 2 || limiter.costs[0] != 1 || limiter.costs[1] != 5 {
+		t.Fatalf("Expected acquisitions costing [1 5], got %v", limiter.costs)
+	}
+	if limiter.releases != 2 || limiter.held != 0 {
+		t.Fatalf("Expected 2 releases, got %d with %d held", limiter.releases, limiter.held)
+	}
+}
+
+func TestClient_DistributedLimiterError(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	limiter := &fakeDistributedLimiter{err: errors.New("redis unavailable")}
+	c := testConfig(server.URL)
+	c.DistributedLimiter = limiter
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	if err == nil || !strings.Contains(err.Error(), "redis unavailable") {
+		t.Fatalf("Expected the limiter error, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Fatalf("Expected no request to be sent, got %d", n)
+	}
+	if limiter.releases != 0 {
+		t.Fatalf("Expected no release after a failed acquisition, got %d", limiter.releases)
+	}
+}
+
+func TestNoopDistributedLimiter(t *testing.T) {
+	var limiter DistributedLimiter = NoopDistributedLimiter{}
+	if err := limiter.Acquire(context.Background(), 1); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	limiter.Release()
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// Do sends req to the DigitalOcean API or to Spaces depending on its host.
+// API requests go through the client returned by GodoClient, with its
+// authentication and rate limiting. Spaces requests are signed with the
+// Spaces credentials and sent through the session of the region they target.
+// Requests to any other host are rejected.
+func (c *CombinedConfig) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+
+	if strings.EqualFold(req.URL.Host, c.client.BaseURL.Host) {
+		return c.httpClient.Do(req)
+	}
+
+	region, ok := c.spacesRegion(req.URL.Host)
+	if !ok {
+		return nil, fmt.Errorf("host %q is neither the DigitalOcean API nor a Spaces endpoint", req.URL.Host)
+	}
+	return c.doSpaces(req, region)
+}
+
+// spacesRegion returns the region whose Spaces endpoint serves host, either
+// directly or as a bucket subdomain.
+func (c *CombinedConfig) spacesRegion(host string) (string, bool) {
+	host = strings.ToLower(host)
+
+	for _, region := range spacesRegions {
+		endpoint, err := c.spacesEndpoints.endpoint(region)
+		if err != nil {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		endpointHost := strings.ToLower(u.Host)
+		if host == endpointHost || strings.HasSuffix(host, "."+endpointHost) {
+			return region, true
+		}
+	}
+	return "", false
+}
+
+// doSpaces signs req for the Spaces session of region and sends it.
+func (c *CombinedConfig) doSpaces(req *http.Request, region string) (*http.Response, error) {
+	sess, err := c.SpacesClient(region)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature covers the payload, so the body has to be seekable.
+	var body io.ReadSeeker
+	if req.Body != nil && req.Body != http.NoBody {
+		buf, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req = req.Clone(req.Context())
+	signer := v4.NewSigner(sess.Config.Credentials)
+	if _, err := signer.Sign(req, body, "s3", aws.StringValue(sess.Config.Region), time.Now()); err != nil {
+		return nil, fmt.Errorf("signing Spaces request: %w", err)
+	}
+
+	client := sess.Config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCombinedConfig_Do(t *testing.T) {
+	var apiAuth, spacesAuth, spacesBody string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiAuth = r.Header.Get("Authorization")
+	}))
+	defer api.Close()
+	spaces := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spacesAuth = r.Header.Get("Authorization")
+		buf := new(strings.Builder)
+		if r.Body != nil {
+			_, _ = io.Copy(buf, r.Body)
+		}
+		spacesBody = buf.String()
+	}))
+	defer spaces.Close()
+
+	conf := testSpacesConfig()
+	conf.APIEndpoint = api.URL
+	conf.SpacesAPIEndpoint = spaces.URL + "/{{.Region}}"
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	t.Run("api", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, api.URL+"/v2/account", nil)
+		resp, err := client.Do(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+
+		if apiAuth != "Bearer test-token" {
+			t.Fatalf("Expected the API token, got %q", apiAuth)
+		}
+	})
+
+	t.Run("spaces", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPut, spaces.URL+"/nyc3/my-bucket/object.txt", strings.NewReader("hello"))
+		resp, err := client.Do(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+
+		if !strings.HasPrefix(spacesAuth, "AWS4-HMAC-SHA256 Credential=access/") {
+			t.Fatalf("Expected a Spaces signature, got %q", spacesAuth)
+		}
+		if spacesBody != "hello" {
+			t.Fatalf("Expected the body to be sent, got %q", spacesBody)
+		}
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+		if _, err := client.Do(context.Background(), req); err == nil || !strings.Contains(err.Error(), "neither the DigitalOcean API nor a Spaces endpoint") {
+			t.Fatalf("Expected the request to be rejected, got %v", err)
+		}
+	})
+}
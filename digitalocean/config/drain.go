@@ -0,0 +1,105 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// inFlightTracker counts the requests that are being sent.
+type inFlightTracker struct {
+	mu    sync.Mutex
+	count int
+	peak  int
+	idle  chan struct{}
+}
+
+func (t *inFlightTracker) acquire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.count == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.count++
+	if t.count > t.peak {
+		t.peak = t.count
+	}
+}
+
+// peakCount returns the largest number of requests in flight at once.
+func (t *inFlightTracker) peakCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.peak
+}
+
+func (t *inFlightTracker) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count--
+	if t.count == 0 {
+		close(t.idle)
+	}
+}
+
+// wait blocks until no request is in flight or ctx is done.
+func (t *inFlightTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.count == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// inFlightTransport tracks logical requests, including all of their retries,
+// in an inFlightTracker.
+type inFlightTransport struct {
+	base    http.RoundTripper
+	tracker *inFlightTracker
+}
+
+func (t *inFlightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.tracker.acquire()
+	defer t.tracker.release()
+
+	return t.base.RoundTrip(req)
+}
+
+// Drain blocks until every in-flight API request has received its response,
+// or ctx is done. Requests started while draining are waited for as well.
+func (c *CombinedConfig) Drain(ctx context.Context) error {
+	return c.inFlight.wait(ctx)
+}
+
+// CloseContext drains the client like Drain, then closes it like Close. If
+// ctx is done before the in-flight requests complete, the requests still in
+// flight are aborted and CloseContext returns the error of ctx, so that a
+// stuck connection cannot hold up the shutdown. Idle connections are closed
+// in either case. Once requests have been aborted, the client fails every
+// request it is given.
+func (c *CombinedConfig) CloseContext(ctx context.Context) error {
+	err := c.Drain(ctx)
+	if err != nil {
+		c.abort()
+	}
+	if c.baseTransport != nil {
+		c.baseTransport.CloseIdleConnections()
+	}
+
+	if closeErr := c.Close(); closeErr != nil {
+		return closeErr
+	}
+	return err
+}
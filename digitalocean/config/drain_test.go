@@ -0,0 +1,110 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	received := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-unblock
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if err := client.Drain(context.Background()); err != nil {
+		t.Fatalf("Expected an idle client to drain immediately, got %s", err)
+	}
+
+	done := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+			done <- struct{}{}
+		}()
+	}
+	<-received
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Drain(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected context.DeadlineExceeded while requests are in flight, got %v", err)
+	}
+
+	close(unblock)
+	if err := client.Drain(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	select {
+	case <-done:
+		<-done
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the requests to have completed")
+	}
+}
+
+func TestCloseContext(t *testing.T) {
+	received := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+		done <- err
+	}()
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := client.CloseContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected context.DeadlineExceeded with a stuck request, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected CloseContext to return by the timeout, took %s", elapsed)
+	}
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("Expected the stuck request to be aborted")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the stuck request to have been aborted")
+	}
+}
+
+func TestCloseContext_Idle(t *testing.T) {
+	client, err := testConfig("https://api.digitalocean.com").Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if err := client.CloseContext(context.Background()); err != nil {
+		t.Fatalf("Expected an idle client to close without error, got %s", err)
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// EnvironmentMetricsSink is a MetricsSink that can label what it records with
+// Config.Environment. Client passes the environment to the sinks
+// implementing it.
+type EnvironmentMetricsSink interface {
+	MetricsSink
+
+	// WithEnvironment returns a sink recording to the same metrics, labeled
+	// with environment.
+	WithEnvironment(environment string) MetricsSink
+}
+
+// metricsSink returns Metrics, labeled with Environment when it supports it.
+func (c *Config) metricsSink() MetricsSink {
+	if sink, ok := c.Metrics.(EnvironmentMetricsSink); ok && c.Environment != "" {
+		return sink.WithEnvironment(c.Environment)
+	}
+	return c.Metrics
+}
+
+// withEnvironment returns a retryablehttp logger that adds environment to
+// every message of logger, which must be a retryablehttp.Logger or
+// retryablehttp.LeveledLogger.
+func withEnvironment(logger interface{}, environment string) interface{} {
+	switch l := logger.(type) {
+	case retryablehttp.Logger:
+		return &environmentLogger{base: l, environment: environment}
+	case retryablehttp.LeveledLogger:
+		return &environmentLeveledLogger{base: l, environment: environment}
+	}
+	return logger
+}
+
+// environmentLogger appends the environment to the messages of a
+// retryablehttp.Logger, keeping the level prefix first.
+type environmentLogger struct {
+	base        retryablehttp.Logger
+	environment string
+}
+
+func (l *environmentLogger) Printf(format string, args ...interface{}) {
+	l.base.Printf("%s (environment: %s)", fmt.Sprintf(format, args...), l.environment)
+}
+
+// environmentLeveledLogger adds the environment to the key-value pairs of a
+// retryablehttp.LeveledLogger.
+type environmentLeveledLogger struct {
+	base        retryablehttp.LeveledLogger
+	environment string
+}
+
+func (l *environmentLeveledLogger) with(keysAndValues []interface{}) []interface{} {
+	return append(keysAndValues[:len(keysAndValues):len(keysAndValues)], "environment", l.environment)
+}
+
+func (l *environmentLeveledLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.base.Error(msg, l.with(keysAndValues)...)
+}
+
+func (l *environmentLeveledLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.base.Info(msg, l.with(keysAndValues)...)
+}
+
+func (l *environmentLeveledLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.base.Debug(msg, l.with(keysAndValues)...)
+}
+
+func (l *environmentLeveledLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.base.Warn(msg, l.with(keysAndValues)...)
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIError is the standard error body returned by the DigitalOcean API.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%d %s: %s", e.StatusCode, e.ID, e.Message)
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request %q)", msg, e.RequestID)
+	}
+	return msg
+}
+
+// RateLimitDeadlineError is returned when a request is rate limited and the
+// advertised reset is later than the deadline of the request's context, so
+// waiting for it could never succeed.
+type RateLimitDeadlineError struct {
+	Reset     time.Duration
+	Remaining time.Duration
+}
+
+func (e *RateLimitDeadlineError) Error() string {
+	return fmt.Sprintf("rate-limit reset in %s exceeds remaining context deadline of %s",
+		e.Reset.Round(time.Second), e.Remaining.Round(time.Millisecond))
+}
+
+// QuotaExceededError is returned, without retrying, for a 429 response whose
+// body carries the QuotaExceededMarker: the quota will not replenish by
+// waiting for the rate limit window to reset.
+type QuotaExceededError struct {
+	// APIError is the error returned by the API, if the body could be
+	// decoded as one.
+	APIError *APIError
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.APIError == nil {
+		return "API quota exceeded"
+	}
+	return fmt.Sprintf("API quota exceeded: %s", e.APIError)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	if e.APIError == nil {
+		return nil
+	}
+	return e.APIError
+}
+
+// isRetryPolicyError reports whether err was returned by the retry policy to
+// explain why a request was not retried.
+func isRetryPolicyError(err error) bool {
+	var deadlineErr *RateLimitDeadlineError
+	var quotaErr *QuotaExceededError
+	return errors.As(err, &deadlineErr) || errors.As(err, &quotaErr) || errors.Is(err, ErrSoftDeadlineExceeded)
+}
+
+// parseAPIError decodes the DigitalOcean error body of resp, decompressing it
+// if needed and leaving the body readable. It returns nil when the body is
+// not a DigitalOcean error.
+func parseAPIError(resp *http.Response) *APIError {
+	body, err := inspectBody(resp)
+	if err != nil || len(body) == 0 {
+		return nil
+	}
+
+	apiErr := &APIError{}
+	if err := json.Unmarshal(body, apiErr); err != nil {
+		return nil
+	}
+	if apiErr.ID == "" && apiErr.Message == "" {
+		return nil
+	}
+
+	apiErr.StatusCode = resp.StatusCode
+	return apiErr
+}
+
+// retryErrorHandler is called once retries are exhausted. Unlike the
+// retryablehttp default, it surfaces the DigitalOcean error from the last
+// response instead of discarding its body.
+func retryErrorHandler(resp *http.Response, err error, numTries int) (*http.Response, error) {
+	if errors.Is(err, errRetriesExhausted) {
+		err = nil
+	}
+	desc := fmt.Sprintf("giving up after %d attempt(s)", numTries)
+
+	if resp == nil {
+		// Transport errors of http.Client name the request that failed.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			desc = fmt.Sprintf("%s %s %s", strings.ToUpper(urlErr.Op), urlErr.URL, desc)
+		}
+		return nil, fmt.Errorf("%s: %w", desc, err)
+	}
+	defer resp.Body.Close()
+
+	if !isRetryPolicyError(err) {
+		if apiErr := parseAPIError(resp); apiErr != nil {
+			err = apiErr
+		}
+	}
+
+	if resp.Request != nil {
+		desc = fmt.Sprintf("%s %s %s", resp.Request.Method, resp.Request.URL, desc)
+	}
+
+	if err == nil {
+		return nil, fmt.Errorf("%s: unexpected HTTP status %s", desc, resp.Status)
+	}
+	return nil, fmt.Errorf("%s: %w", desc, err)
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAPIError(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Status   int
+		Body     string
+		Expected *APIError
+	}{
+		{
+			Name:   "unprocessable entity",
+			Status: http.StatusUnprocessableEntity,
+			Body:   `{"id":"unprocessable_entity","message":"Name is already in use."}`,
+			Expected: &APIError{
+				StatusCode: http.StatusUnprocessableEntity,
+				ID:         "unprocessable_entity",
+				Message:    "Name is already in use.",
+			},
+		},
+		{
+			Name:   "with request id",
+			Status: http.StatusServiceUnavailable,
+			Body:   `{"id":"service_unavailable","message":"Server is overloaded.","request_id":"abc-123"}`,
+			Expected: &APIError{
+				StatusCode: http.StatusServiceUnavailable,
+				ID:         "service_unavailable",
+				Message:    "Server is overloaded.",
+				RequestID:  "abc-123",
+			},
+		},
+		{
+			Name:   "not json",
+			Status: http.StatusBadGateway,
+			Body:   `<html>Bad Gateway</html>`,
+		},
+		{
+			Name:   "unrelated json",
+			Status: http.StatusBadGateway,
+			Body:   `{"droplets":[]}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			resp := testResponse(tc.Status, nil)
+			resp.Body = io.NopCloser(strings.NewReader(tc.Body))
+
+			apiErr := parseAPIError(resp)
+			if tc.Expected == nil {
+				if apiErr != nil {
+					t.Fatalf("Expected no API error, got %+v", apiErr)
+				}
+			} else if apiErr == nil || *apiErr != *tc.Expected {
+				t.Fatalf("Expected %+v, got %+v", tc.Expected, apiErr)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tc.Body {
+				t.Fatalf("Expected the body to remain readable, got %q", body)
+			}
+		})
+	}
+}
+
+func TestRetryErrorHandler_SurfacesAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"id":"service_unavailable","message":"Server is overloaded."}`)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.HTTPRetryMax = 1
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an *APIError, got %s", err)
+	}
+	if apiErr.ID != "service_unavailable" || apiErr.Message != "Server is overloaded." {
+		t.Fatalf("Unexpected API error: %+v", apiErr)
+	}
+	if !strings.Contains(err.Error(), "giving up after 2 attempt(s)") {
+		t.Fatalf("Expected the attempt count in the error, got %s", err)
+	}
+}
+
+func TestRetryErrorHandler_TransportErrorNamesRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	endpoint := server.URL
+	server.Close()
+
+	conf := testConfig(endpoint)
+	conf.HTTPRetryMax = 1
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	expected := "GET " + endpoint + "/v2/account giving up after 2 attempt(s)"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("Expected an error containing %q, got %v", expected, err)
+	}
+}
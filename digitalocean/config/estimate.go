@@ -0,0 +1,54 @@
+package config
+
+import "time"
+
+// rateLimitWindow is the length of the DigitalOcean API rate limit window.
+const rateLimitWindow = time.Hour
+
+// EstimateDuration estimates how long it will take to send requestCount
+// requests given the client-side throttle and the remaining API quota
+// captured from the most recent response. Network latency is not accounted
+// for, so the estimate is a lower bound.
+func (c *CombinedConfig) EstimateDuration(requestCount int) time.Duration {
+	if requestCount <= 0 {
+		return 0
+	}
+
+	var estimate time.Duration
+	if c.requestsPerSecond > 0 {
+		burst := c.requestsBurst
+		if burst < 1 {
+			burst = 1
+		}
+		if throttled := requestCount - burst; throttled > 0 {
+			estimate = time.Duration(float64(throttled) / c.requestsPerSecond * float64(time.Second))
+		}
+	}
+
+	if quota := quotaWait(c.rateLimits, requestCount, time.Now()); quota > estimate {
+		estimate = quota
+	}
+
+	return estimate
+}
+
+// quotaWait returns how long requestCount requests must wait for the API
+// quota to replenish, based on the last observed rate limit.
+func quotaWait(tracker *rateLimitTracker, requestCount int, now time.Time) time.Duration {
+	rate, observed := tracker.state()
+	if !observed || rate.Limit <= 0 || requestCount <= rate.Remaining {
+		return 0
+	}
+
+	untilReset := rate.Reset.Sub(now)
+	if untilReset < 0 {
+		untilReset = 0
+	}
+
+	// Requests beyond the remaining quota wait for the reset, and for one
+	// more full window for every additional limit's worth of requests.
+	excess := requestCount - rate.Remaining
+	windows := (excess - 1) / rate.Limit
+
+	return untilReset + time.Duration(windows)*rateLimitWindow
+}
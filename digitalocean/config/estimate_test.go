@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestEstimateDuration(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Rate      float64
+		Burst     int
+		Quota     *godo.Rate
+		Requests  int
+		Expected  time.Duration
+		Tolerance time.Duration
+	}{
+		{
+			Name:     "unthrottled",
+			Requests: 100,
+		},
+		{
+			Name:     "no requests",
+			Rate:     1,
+			Requests: 0,
+		},
+		{
+			Name:     "throttled",
+			Rate:     5,
+			Requests: 101,
+			Expected: 20 * time.Second,
+		},
+		{
+			Name:     "throttled with burst",
+			Rate:     10,
+			Burst:    11,
+			Requests: 111,
+			Expected: 10 * time.Second,
+		},
+		{
+			Name:     "quota is sufficient",
+			Rate:     10,
+			Quota:    &godo.Rate{Limit: 5000, Remaining: 4000, Reset: godo.Timestamp{Time: time.Now().Add(time.Minute)}},
+			Requests: 11,
+			Expected: time.Second,
+		},
+		{
+			Name:      "quota exhausted",
+			Rate:      10,
+			Quota:     &godo.Rate{Limit: 5000, Remaining: 10, Reset: godo.Timestamp{Time: time.Now().Add(10 * time.Minute)}},
+			Requests:  100,
+			Expected:  10 * time.Minute,
+			Tolerance: time.Second,
+		},
+		{
+			Name:      "several windows",
+			Quota:     &godo.Rate{Limit: 100, Remaining: 0, Reset: godo.Timestamp{Time: time.Now().Add(10 * time.Minute)}},
+			Requests:  250,
+			Expected:  10*time.Minute + 2*time.Hour,
+			Tolerance: time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client := &CombinedConfig{
+				requestsPerSecond: tc.Rate,
+				requestsBurst:     tc.Burst,
+				rateLimits:        &rateLimitTracker{},
+			}
+			if tc.Quota != nil {
+				client.rateLimits.rate = *tc.Quota
+				client.rateLimits.observed = true
+			}
+
+			estimate := client.EstimateDuration(tc.Requests)
+			if diff := tc.Expected - estimate; diff < 0 || diff > tc.Tolerance {
+				t.Fatalf("Expected an estimate of %s, got %s", tc.Expected, estimate)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// etagCacheEntry is a response cached by the ETag cache.
+type etagCacheEntry struct {
+	ETag       string      `json:"etag"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// etagCacheTransport revalidates GET requests with the ETag of the last
+// response and serves the cached response when the API answers 304 Not
+// Modified. Since every request is revalidated, entries never go stale. It
+// sits below the OAuth transport so that entries are keyed by credentials as
+// well. Responses carrying credentials are never cached, see
+// credentialPaths. Store failures are logged and the request is sent
+// uncached.
+type etagCacheTransport struct {
+	base  http.RoundTripper
+	store CacheStore
+}
+
+// requestCacheKey identifies a request by URL and by a hash of its
+// credentials, so that responses are never shared between tokens.
+func requestCacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + " " + hex.EncodeToString(sum[:8])
+}
+
+// credentialPaths match the API paths whose responses carry credentials,
+// such as kubeconfigs and database passwords, which must not be written to a
+// CacheStore.
+var credentialPaths = []string{
+	"/v2/kubernetes/clusters/*/kubeconfig",
+	"/v2/kubernetes/clusters/*/credentials",
+	"/v2/databases",
+	"/v2/databases/*",
+	"/v2/databases/*/ca",
+	"/v2/databases/*/users",
+	"/v2/databases/*/users/*",
+	"/v2/databases/*/replicas",
+	"/v2/databases/*/replicas/*",
+	"/v2/registry/docker-credentials",
+}
+
+// isCredentialPath reports whether the response to a request for urlPath
+// may carry credentials. The API endpoint may have a base path ahead of
+// /v2/.
+func isCredentialPath(urlPath string) bool {
+	if i := strings.Index(urlPath, "/v2/"); i > 0 {
+		urlPath = urlPath[i:]
+	}
+	urlPath = strings.TrimSuffix(urlPath, "/")
+	for _, pattern := range credentialPaths {
+		if ok, _ := path.Match(pattern, urlPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *etagCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" || isCredentialPath(req.URL.Path) {
+		return t.base.RoundTrip(req)
+	}
+
+	key := requestCacheKey(req)
+	entry, ok := t.load(key)
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// Headers of the 304, such as the rate limit, are more recent.
+		header := entry.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		resp.StatusCode = entry.StatusCode
+		resp.Status = http.StatusText(entry.StatusCode)
+		resp.Header = header
+		resp.ContentLength = int64(len(entry.Body))
+		resp.Body = io.NopCloser(bytes.NewReader(entry.Body))
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := bufferBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	t.save(key, etagCacheEntry{ETag: etag, StatusCode: resp.StatusCode, Header: resp.Header, Body: body})
+
+	return resp, nil
+}
+
+func (t *etagCacheTransport) load(key string) (etagCacheEntry, bool) {
+	var entry etagCacheEntry
+
+	value, ok, err := t.store.Get(key)
+	if err != nil {
+		log.Printf("[WARN] Unable to read cached response: %s", err)
+		return entry, false
+	}
+	if !ok {
+		return entry, false
+	}
+
+	if err := json.Unmarshal(value, &entry); err != nil || entry.ETag == "" {
+		log.Printf("[WARN] Ignoring malformed cached response")
+		return entry, false
+	}
+	return entry, true
+}
+
+func (t *etagCacheTransport) save(key string, entry etagCacheEntry) {
+	value, err := json.Marshal(entry)
+	if err == nil {
+		err = t.store.Set(key, value)
+	}
+	if err != nil {
+		log.Printf("[WARN] Unable to cache response: %s", err)
+	}
+}
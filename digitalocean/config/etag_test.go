@@ -0,0 +1,146 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_ETagCacheAcrossRuns(t *testing.T) {
+	var mu sync.Mutex
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		mu.Unlock()
+
+		w.Header().Set(headerRateRemaining, "4999")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, `{"account":{"email":"sammy@digitalocean.com"}}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for run := 0; run < 2; run++ {
+		store, err := NewFileCacheStore(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		conf := testConfig(server.URL)
+		conf.EnableETagCache = true
+		conf.ETagCacheStore = store
+
+		client, err := conf.Client()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		account, resp, err := client.GodoClient().Account.Get(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error on run %d: %s", run, err)
+		}
+		if account.Email != "sammy@digitalocean.com" {
+			t.Fatalf("Expected the account on run %d, got %+v", run, account)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected a 200 on run %d, got %d", run, resp.StatusCode)
+		}
+	}
+
+	if len(conditional) != 2 || conditional[0] != "" || conditional[1] != `"v1"` {
+		t.Fatalf("Expected the second run to revalidate the cached ETag, got %q", conditional)
+	}
+}
+
+func TestClient_ETagCacheKeyedByToken(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+	}))
+	defer server.Close()
+
+	store := NewMemoryCacheStore()
+	for _, token := range []string{"first-token", "second-token"} {
+		conf := testConfig(server.URL)
+		conf.Token = token
+		conf.EnableETagCache = true
+		conf.ETagCacheStore = store
+
+		client, err := conf.Client()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(conditional) != 2 || conditional[1] != "" {
+		t.Fatalf("Expected entries not to be shared between tokens, got %q", conditional)
+	}
+}
+
+func TestClient_ETagCacheSkipsCredentials(t *testing.T) {
+	var conditional int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			atomic.AddInt32(&conditional, 1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	store := NewMemoryCacheStore()
+	conf := testConfig(server.URL + "/proxy/")
+	conf.EnableETagCache = true
+	conf.ETagCacheStore = store
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "v2/kubernetes/clusters/1234/kubeconfig"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&conditional); n != 0 {
+		t.Fatalf("Expected the kubeconfig not to be revalidated, got %d conditional requests", n)
+	}
+	if len(store.entries) != 0 {
+		t.Fatalf("Expected no cached entries, got %d", len(store.entries))
+	}
+}
+
+func TestIsCredentialPath(t *testing.T) {
+	cases := map[string]bool{
+		"/v2/kubernetes/clusters/1234/kubeconfig":  true,
+		"/v2/kubernetes/clusters/1234/credentials": true,
+		"/v2/databases/1234/ca":                    true,
+		"/v2/databases/1234/users/doadmin":         true,
+		"/v2/databases/1234/":                      true,
+		"/proxy/v2/databases/1234/users":           true,
+		"/v2/registry/docker-credentials":          true,
+		"/v2/kubernetes/clusters/1234":             false,
+		"/v2/droplets":                             false,
+	}
+
+	for urlPath, expected := range cases {
+		if got := isCredentialPath(urlPath); got != expected {
+			t.Errorf("Expected isCredentialPath(%q) to be %t, got %t", urlPath, expected, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitEvent describes a backoff before retrying a request.
+type RateLimitEvent struct {
+	Time time.Time
+	// StatusCode is the status of the response that was retried, or zero
+	// when the request failed without a response.
+	StatusCode int
+	// Reset is the rate limit reset advertised by the response, if any.
+	Reset time.Time
+	// Sleep is the backoff computed before the next attempt.
+	Sleep time.Duration
+}
+
+// rateLimitEventLog keeps the most recent events in a ring buffer.
+type rateLimitEventLog struct {
+	mu     sync.Mutex
+	events []RateLimitEvent
+	next   int
+	full   bool
+}
+
+func newRateLimitEventLog(size int) *rateLimitEventLog {
+	return &rateLimitEventLog{events: make([]RateLimitEvent, size)}
+}
+
+// add records event, dropping the oldest event once the buffer is full.
+func (l *rateLimitEventLog) add(event RateLimitEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events[l.next] = event
+	l.next = (l.next + 1) % len(l.events)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// recent returns the recorded events, oldest first.
+func (l *rateLimitEventLog) recent() []RateLimitEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]RateLimitEvent(nil), l.events[:l.next]...)
+	}
+	events := make([]RateLimitEvent, 0, len(l.events))
+	events = append(events, l.events[l.next:]...)
+	return append(events, l.events[:l.next]...)
+}
+
+// recordBackoff wraps backoff so that every wait it computes is recorded.
+func (l *rateLimitEventLog) recordBackoff(backoff func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration) func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	return func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		sleep := backoff(min, max, attemptNum, resp)
+
+		event := RateLimitEvent{Time: time.Now(), Sleep: sleep}
+		if resp != nil {
+			event.StatusCode = resp.StatusCode
+			if epoch, err := strconv.ParseInt(resp.Header.Get(headerRateReset), 10, 64); err == nil {
+				event.Reset = time.Unix(epoch, 0)
+			}
+		}
+		l.add(event)
+
+		return sleep
+	}
+}
+
+// RecentRateLimitEvents returns the last RateLimitEventBufferSize backoffs
+// of the client, oldest first.
+func (c *CombinedConfig) RecentRateLimitEvents() []RateLimitEvent {
+	if c.rateLimitEvents == nil {
+		return nil
+	}
+	return c.rateLimitEvents.recent()
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitEventLog(t *testing.T) {
+	log := newRateLimitEventLog(3)
+	if events := log.recent(); len(events) != 0 {
+		t.Fatalf("Expected no events, got %v", events)
+	}
+
+	for i := 1; i <= 5; i++ {
+		log.add(RateLimitEvent{Sleep: time.Duration(i)})
+
+		events := log.recent()
+		expected := i
+		if expected > 3 {
+			expected = 3
+		}
+		if len(events) != expected {
+			t.Fatalf("Expected %d events, got %d", expected, len(events))
+		}
+		for j, event := range events {
+			if want := time.Duration(i - len(events) + 1 + j); event.Sleep != want {
+				t.Fatalf("Expected the most recent events oldest first, got %v", events)
+			}
+		}
+	}
+}
+
+func TestClient_RecentRateLimitEvents(t *testing.T) {
+	reset := time.Now().Add(-time.Second).Unix()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.Header().Set(headerRateReset, strconv.FormatInt(reset, 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RateLimitEventBufferSize = 10
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	events := client.RecentRateLimitEvents()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %v", events)
+	}
+	if events[0].StatusCode != http.StatusTooManyRequests || events[0].Reset.Unix() != reset {
+		t.Fatalf("Expected the 429 and its reset first, got %+v", events[0])
+	}
+	if events[1].StatusCode != http.StatusBadGateway || !events[1].Reset.IsZero() || events[1].Sleep <= 0 {
+		t.Fatalf("Expected the 502 second, got %+v", events[1])
+	}
+	if events[0].Time.IsZero() || events[1].Time.Before(events[0].Time) {
+		t.Fatalf("Expected events to be timestamped in order, got %+v", events)
+	}
+
+	if disabled, _ := testConfig(server.URL).Client(); disabled.RecentRateLimitEvents() != nil {
+		t.Fatalf("Expected no events when disabled")
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+
+	"github.com/digitalocean/godo"
+)
+
+// expvarMu serializes the lookup and creation of published maps, since
+// expvar panics when the same name is published twice.
+var expvarMu sync.Mutex
+
+// expvarStats publishes the rate limit state, request and retry counters of
+// a client under a single expvar map. Clients sharing a prefix share the map.
+type expvarStats struct {
+	vars *expvar.Map
+}
+
+func newExpvarStats(prefix string) (*expvarStats, error) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if v := expvar.Get(prefix); v != nil {
+		vars, ok := v.(*expvar.Map)
+		if !ok {
+			return nil, fmt.Errorf("expvar %q is already published as a %T", prefix, v)
+		}
+		return &expvarStats{vars: vars}, nil
+	}
+
+	return &expvarStats{vars: expvar.NewMap(prefix)}, nil
+}
+
+// observeRate records the rate limit advertised by the last response.
+func (s *expvarStats) observeRate(rate godo.Rate) {
+	s.set("ratelimit_limit", int64(rate.Limit))
+	s.set("ratelimit_remaining", int64(rate.Remaining))
+	if !rate.Reset.IsZero() {
+		s.set("ratelimit_reset", rate.Reset.Unix())
+	}
+}
+
+// setEnvironment publishes the Environment of the client.
+func (s *expvarStats) setEnvironment(environment string) {
+	v := new(expvar.String)
+	v.Set(environment)
+	s.vars.Set("environment", v)
+}
+
+// requested counts a request to the given resource type.
+func (s *expvarStats) requested(resourceType string) {
+	expvarMu.Lock()
+	byResource, ok := s.vars.Get("requests_by_resource").(*expvar.Map)
+	if !ok {
+		byResource = new(expvar.Map).Init()
+		s.vars.Set("requests_by_resource", byResource)
+	}
+	expvarMu.Unlock()
+
+	byResource.Add(resourceType, 1)
+}
+
+// retried counts a retried attempt.
+func (s *expvarStats) retried() {
+	s.vars.Add("retries", 1)
+}
+
+func (s *expvarStats) set(key string, value int64) {
+	v, ok := s.vars.Get(key).(*expvar.Int)
+	if !ok {
+		v = new(expvar.Int)
+		s.vars.Set(key, v)
+	}
+	v.Set(value)
+}
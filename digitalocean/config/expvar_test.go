@@ -0,0 +1,110 @@
+package config
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_ExpvarPrefix(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set(headerRateLimit, "5000")
+		w.Header().Set(headerRateRemaining, strconv.Itoa(5000-int(n)))
+		w.Header().Set(headerRateReset, strconv.FormatInt(reset, 10))
+		if n == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.ExpvarPrefix = "digitalocean_test"
+
+	// The map is shared by every client using the prefix, so only count the
+	// retries of this test.
+	var retriesBefore int64
+	if vars, ok := expvar.Get("digitalocean_test").(*expvar.Map); ok {
+		if retries, ok := vars.Get("retries").(*expvar.Int); ok {
+			retriesBefore = retries.Value()
+		}
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	vars, ok := expvar.Get("digitalocean_test").(*expvar.Map)
+	if !ok {
+		t.Fatalf("Expected an expvar map to be published")
+	}
+
+	expected := map[string]string{
+		"ratelimit_limit":     "5000",
+		"ratelimit_remaining": "4998",
+		"ratelimit_reset":     strconv.FormatInt(reset, 10),
+		"retries":             strconv.FormatInt(retriesBefore+1, 10),
+	}
+	for key, value := range expected {
+		v := vars.Get(key)
+		if v == nil || v.String() != value {
+			t.Fatalf("Expected %s to be %s, got %v", key, value, v)
+		}
+	}
+
+	byResource, ok := vars.Get("requests_by_resource").(*expvar.Map)
+	if !ok {
+		t.Fatalf("Expected requests to be counted by resource type")
+	}
+	if v, ok := byResource.Get("account").(*expvar.Int); !ok || v.Value() < 1 {
+		t.Fatalf("Expected the request to be counted under account, got %s", byResource)
+	}
+
+	// A second client with the same prefix reuses the published map.
+	if _, err := c.Client(); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+}
+
+func TestClient_ExpvarPrefixConflict(t *testing.T) {
+	if expvar.Get("digitalocean_test_conflict") == nil {
+		expvar.NewString("digitalocean_test_conflict")
+	}
+
+	c := testConfig("https://api.digitalocean.com")
+	c.ExpvarPrefix = "digitalocean_test_conflict"
+
+	if _, err := c.Client(); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
+
+func TestClient_ExpvarEnvironment(t *testing.T) {
+	c := testConfig("https://api.digitalocean.com")
+	c.ExpvarPrefix = "digitalocean_test_environment"
+	c.Environment = "staging"
+
+	if _, err := c.Client(); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	vars, ok := expvar.Get("digitalocean_test_environment").(*expvar.Map)
+	if !ok {
+		t.Fatalf("Expected an expvar map to be published")
+	}
+	if got := vars.Get("environment"); got == nil || got.String() != `"staging"` {
+		t.Fatalf("Expected the environment to be published, got %v", got)
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+import "net/http"
+
+// hostHeaderTransport sends every request with the given Host header while
+// still connecting to the host of the request URL.
+type hostHeaderTransport struct {
+	base http.RoundTripper
+	host string
+}
+
+func (t *hostHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Host = t.host
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClient_HostHeaderOverride(t *testing.T) {
+	var hosts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		if len(hosts) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.HostHeaderOverride = "api.internal.example.com"
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Every attempt reached the test server, with the overridden header.
+	if len(hosts) != 2 || hosts[0] != c.HostHeaderOverride || hosts[1] != c.HostHeaderOverride {
+		t.Fatalf("Expected 2 attempts with Host %s, got %q", c.HostHeaderOverride, hosts)
+	}
+
+	endpoint, _ := url.Parse(server.URL)
+	if resp.Request.URL.Host != endpoint.Host {
+		t.Fatalf("Expected the request to target %s, got %s", endpoint.Host, resp.Request.URL.Host)
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// hourlyBudgetReserve is the fraction of the hourly limit below which
+// requests start being paced over the rest of the window.
+const hourlyBudgetReserve = 0.1
+
+// hourlyBudgetDelay returns the spacing between requests needed to make the
+// remaining hourly budget last until the window resets. It is zero while
+// more than hourlyBudgetReserve of the limit remains.
+func hourlyBudgetDelay(rate godo.Rate, now time.Time) time.Duration {
+	if rate.Limit <= 0 || rate.Reset.IsZero() {
+		return 0
+	}
+	if float64(rate.Remaining) > float64(rate.Limit)*hourlyBudgetReserve {
+		return 0
+	}
+
+	untilReset := rate.Reset.Sub(now)
+	if untilReset <= 0 {
+		return 0
+	}
+
+	if rate.Remaining <= 0 {
+		return untilReset
+	}
+	return untilReset / time.Duration(rate.Remaining)
+}
+
+// hourlyBudgetTransport spreads requests over the rest of the hourly rate
+// limit window once the budget is nearly exhausted, so that a run does not
+// burn the whole hourly quota in its first few minutes.
+type hourlyBudgetTransport struct {
+	base    http.RoundTripper
+	tracker *rateLimitTracker
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (t *hourlyBudgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.reserve(time.Now()); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// reserve books the next dispatch slot and returns how long to wait for it.
+func (t *hourlyBudgetTransport) reserve(now time.Time) time.Duration {
+	rate, observed := t.tracker.state()
+	if !observed {
+		return 0
+	}
+
+	delay := hourlyBudgetDelay(rate, now)
+	if delay <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	slot := t.next
+	if slot.Before(now) {
+		slot = now
+	}
+	t.next = slot.Add(delay)
+
+	return slot.Sub(now)
+}
+
+// clear forgets the dispatch slots booked so far.
+func (t *hourlyBudgetTransport) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.next = time.Time{}
+}
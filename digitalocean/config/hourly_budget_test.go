@@ -0,0 +1,79 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestHourlyBudgetDelay(t *testing.T) {
+	now := time.Now()
+	reset := godo.Timestamp{Time: now.Add(100 * time.Second)}
+
+	cases := []struct {
+		Remaining int
+		Expected  time.Duration
+	}{
+		{Remaining: 5000, Expected: 0},
+		{Remaining: 1000, Expected: 0},
+		{Remaining: 500, Expected: 200 * time.Millisecond},
+		{Remaining: 100, Expected: time.Second},
+		{Remaining: 10, Expected: 10 * time.Second},
+		{Remaining: 0, Expected: 100 * time.Second},
+	}
+
+	for _, tc := range cases {
+		t.Run(strconv.Itoa(tc.Remaining), func(t *testing.T) {
+			rate := godo.Rate{Limit: 5000, Remaining: tc.Remaining, Reset: reset}
+			if delay := hourlyBudgetDelay(rate, now); delay != tc.Expected {
+				t.Fatalf("Expected a delay of %s, got %s", tc.Expected, delay)
+			}
+		})
+	}
+}
+
+func TestHourlyBudgetDelay_ExpiredWindow(t *testing.T) {
+	now := time.Now()
+	rate := godo.Rate{Limit: 5000, Remaining: 0, Reset: godo.Timestamp{Time: now.Add(-time.Second)}}
+
+	if delay := hourlyBudgetDelay(rate, now); delay != 0 {
+		t.Fatalf("Expected no delay once the window reset, got %s", delay)
+	}
+}
+
+func TestRespectHourlyBudget(t *testing.T) {
+	// Each response reports fewer remaining requests with the reset 2s away,
+	// so once below the reserve requests get spaced by 2s/remaining.
+	var remaining int32 = 12
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateLimit, "100")
+		w.Header().Set(headerRateRemaining, strconv.Itoa(int(atomic.AddInt32(&remaining, -1))))
+		w.Header().Set(headerRateReset, strconv.FormatInt(time.Now().Add(2*time.Second).Unix(), 10))
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RespectHourlyBudget = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	// The first two requests see at least 10 remaining; later ones are paced.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Expected requests to be paced, took %s", elapsed)
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// HTTPTiming is the breakdown of the time spent on a single attempt of a
+// request. Phases that did not happen, e.g. DNS on a reused connection, are
+// zero.
+type HTTPTiming struct {
+	Method          string
+	URL             string
+	ReusedConn      bool
+	DNS             time.Duration
+	Connect         time.Duration
+	TLSHandshake    time.Duration
+	TimeToFirstByte time.Duration
+	Total           time.Duration
+}
+
+// traceTransport records the phase timings of every attempt with
+// httptrace, logs them and passes them to onTrace, if set.
+type traceTransport struct {
+	base    http.RoundTripper
+	onTrace func(HTTPTiming)
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		mu                                    sync.Mutex
+		dnsStart, connectStart, tlsStart      time.Time
+		dns, connect, tlsHandshake, firstByte time.Duration
+		reused                                bool
+	)
+
+	start := time.Now()
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			mu.Lock()
+			reused = info.Reused
+			mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			dnsStart = time.Now()
+			mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			dns = time.Since(dnsStart)
+			mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			mu.Lock()
+			connectStart = time.Now()
+			mu.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			mu.Lock()
+			connect = time.Since(connectStart)
+			mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			tlsStart = time.Now()
+			mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			tlsHandshake = time.Since(tlsStart)
+			mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			firstByte = time.Since(start)
+			mu.Unlock()
+		},
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+
+	mu.Lock()
+	timing := HTTPTiming{
+		Method:          req.Method,
+		URL:             req.URL.String(),
+		ReusedConn:      reused,
+		DNS:             dns,
+		Connect:         connect,
+		TLSHandshake:    tlsHandshake,
+		TimeToFirstByte: firstByte,
+		Total:           time.Since(start),
+	}
+	mu.Unlock()
+
+	log.Printf("[DEBUG] %s %s timings: dns=%s connect=%s tls=%s ttfb=%s total=%s reused=%t",
+		timing.Method, timing.URL, timing.DNS, timing.Connect, timing.TLSHandshake,
+		timing.TimeToFirstByte, timing.Total, timing.ReusedConn)
+	if t.onTrace != nil {
+		t.onTrace(timing)
+	}
+
+	return resp, err
+}
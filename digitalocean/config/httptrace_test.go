@@ -0,0 +1,51 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_HTTPTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var timings []HTTPTiming
+	c := testConfig(server.URL)
+	c.EnableHTTPTrace = true
+	c.OnHTTPTrace = func(timing HTTPTiming) {
+		timings = append(timings, timing)
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+	}
+
+	if len(timings) != 2 {
+		t.Fatalf("Expected 2 timings, got %d", len(timings))
+	}
+
+	first := timings[0]
+	if first.Method != http.MethodGet || first.URL != server.URL+"/v2/account" {
+		t.Fatalf("Expected the timing to describe the request, got %s %s", first.Method, first.URL)
+	}
+	if first.ReusedConn || first.Connect <= 0 {
+		t.Fatalf("Expected a connect timing for a new connection, got %+v", first)
+	}
+	if first.TLSHandshake != 0 {
+		t.Fatalf("Expected no TLS handshake over plain HTTP, got %+v", first)
+	}
+	if first.TimeToFirstByte <= 0 || first.Total < first.TimeToFirstByte {
+		t.Fatalf("Expected a time to first byte within the total, got %+v", first)
+	}
+	if !timings[1].ReusedConn {
+		t.Fatalf("Expected the second request to reuse the connection, got %+v", timings[1])
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+// idempotencyKeyHeader is the header used to deduplicate retried mutations.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyKeyTransport attaches an Idempotency-Key header to mutating
+// requests. It sits above the retrying transport so that every attempt of a
+// logical request is sent with the same key.
+type idempotencyKeyTransport struct {
+	base http.RoundTripper
+}
+
+func (t *idempotencyKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isMutatingMethod(req.Method) || req.Header.Get(idempotencyKeyHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(idempotencyKeyHeader, key)
+
+	return t.base.RoundTrip(req)
+}
+
+// isMutatingMethod reports whether the HTTP method may have side effects.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestIdempotencyKey_ReusedAcrossRetries(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(idempotencyKeyHeader))
+		attempt := len(keys)
+		mu.Unlock()
+
+		if attempt == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.EnableIdempotencyKeys = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(keys))
+	}
+	if keys[0] == "" {
+		t.Fatal("Expected an Idempotency-Key header on the first attempt")
+	}
+	if keys[0] != keys[1] {
+		t.Fatalf("Expected the same key on retry, got %q and %q", keys[0], keys[1])
+	}
+}
+
+func TestIdempotencyKey_SkipsSafeMethods(t *testing.T) {
+	var key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get(idempotencyKeyHeader)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.EnableIdempotencyKeys = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/droplets"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if key != "" {
+		t.Fatalf("Expected no Idempotency-Key on GET, got %q", key)
+	}
+}
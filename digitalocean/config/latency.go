@@ -0,0 +1,35 @@
+package config
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// latencyTransport delays every request by a fixed latency plus a random
+// jitter, to simulate a slow API.
+type latencyTransport struct {
+	base    http.RoundTripper
+	latency time.Duration
+	jitter  time.Duration
+}
+
+func (t *latencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay := t.latency
+	if t.jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(t.jitter)))
+	}
+
+	timer := time.NewTimer(delay)
+	select {
+	case <-req.Context().Done():
+		timer.Stop()
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, req.Context().Err()
+	case <-timer.C:
+	}
+
+	return t.base.RoundTrip(req)
+}
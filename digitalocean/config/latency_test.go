@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_SyntheticLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.SyntheticLatency = 50 * time.Millisecond
+	c.SyntheticLatencyJitter = 10 * time.Millisecond
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	start := time.Now()
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < c.SyntheticLatency {
+		t.Fatalf("Expected the request to take at least %s, took %s", c.SyntheticLatency, elapsed)
+	}
+}
+
+func TestClient_SyntheticLatencyCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.SyntheticLatency = time.Minute
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	godoClient := client.GodoClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	req, err := godoClient.NewRequest(ctx, http.MethodGet, "/v2/account", nil)
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+	if _, err := godoClient.Do(ctx, req, nil); err == nil {
+		t.Fatalf("Expected the canceled request to fail")
+	}
+}
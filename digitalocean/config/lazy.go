@@ -0,0 +1,41 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/digitalocean/godo"
+)
+
+// LazyCombinedConfig builds a CombinedConfig on first use. It is safe for
+// concurrent use; the client is built at most once.
+type LazyCombinedConfig struct {
+	config Config
+
+	once   sync.Once
+	client *CombinedConfig
+	err    error
+}
+
+// LazyClient returns a LazyCombinedConfig that builds the client from a copy
+// of c the first time it is used.
+func (c *Config) LazyClient() *LazyCombinedConfig {
+	return &LazyCombinedConfig{config: *c}
+}
+
+// Client builds the client on first use and returns it, along with any
+// error returned by Config.Client.
+func (l *LazyCombinedConfig) Client() (*CombinedConfig, error) {
+	l.once.Do(func() {
+		l.client, l.err = l.config.Client()
+	})
+	return l.client, l.err
+}
+
+// GodoClient builds the client on first use and returns its godo client.
+func (l *LazyCombinedConfig) GodoClient() (*godo.Client, error) {
+	client, err := l.Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.GodoClient(), nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyClient_BuiltOnce(t *testing.T) {
+	var builds int32
+	c := testConfig("https://api.digitalocean.com")
+	c.TransportWrapper = func(base http.RoundTripper) http.RoundTripper {
+		atomic.AddInt32(&builds, 1)
+		return base
+	}
+
+	lazy := c.LazyClient()
+	if n := atomic.LoadInt32(&builds); n != 0 {
+		t.Fatalf("Expected the client not to be built before use, got %d builds", n)
+	}
+
+	var wg sync.WaitGroup
+	clients := make([]*CombinedConfig, 20)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, err := lazy.Client()
+			if err != nil {
+				t.Errorf("Expected no error, got %s", err)
+			}
+			clients[i] = client
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&builds); n != 1 {
+		t.Fatalf("Expected the client to be built once, got %d builds", n)
+	}
+	for _, client := range clients {
+		if client != clients[0] {
+			t.Fatalf("Expected every caller to get the same client")
+		}
+	}
+}
+
+func TestLazyClient_Error(t *testing.T) {
+	c := testConfig("https://api.digitalocean.com")
+	c.Token = ""
+
+	lazy := c.LazyClient()
+	if _, err := lazy.GodoClient(); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if _, err := lazy.Client(); err == nil {
+		t.Fatalf("Expected the error to be returned on every use")
+	}
+}
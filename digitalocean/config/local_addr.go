@@ -0,0 +1,40 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// localAddrDialer dials connections from a rotating set of local addresses,
+// so that they are spread across several source IPs.
+type localAddrDialer struct {
+	addrs []*net.TCPAddr
+	next  uint32
+}
+
+// parseLocalAddresses parses the LocalAddresses of a Config.
+func parseLocalAddresses(addresses []string) ([]*net.TCPAddr, error) {
+	addrs := make([]*net.TCPAddr, 0, len(addresses))
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid local address %q: not an IP address", address)
+		}
+		addrs = append(addrs, &net.TCPAddr{IP: ip})
+	}
+	return addrs, nil
+}
+
+func (d *localAddrDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	n := atomic.AddUint32(&d.next, 1) - 1
+	dialer := &net.Dialer{
+		// Matches the dialer of cleanhttp.DefaultPooledTransport.
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		LocalAddr: d.addrs[n%uint32(len(d.addrs))],
+	}
+	return dialer.DialContext(ctx, network, address)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestClient_LocalAddresses(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			t.Errorf("unable to parse remote address %q: %s", r.RemoteAddr, err)
+		}
+		mu.Lock()
+		seen[host]++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.LocalAddresses = []string{"127.0.0.1", "127.0.0.2"}
+	// Every request must open a new connection to exercise the rotation.
+	conf.DisableKeepAlives = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(seen) != 2 || seen["127.0.0.1"] != 2 || seen["127.0.0.2"] != 2 {
+		t.Fatalf("Expected 2 connections from each local address, got %v", seen)
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestRetryLogger(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	conf := testConfig(server.URL)
+	conf.RetryLogger = logger
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	logged := strings.Join(logger.messages, "\n")
+	if !strings.Contains(logged, "retrying in") {
+		t.Fatalf("Expected the retry to be logged, got %q", logged)
+	}
+}
+
+func TestRetryLogger_Invalid(t *testing.T) {
+	conf := testConfig("https://api.digitalocean.com")
+	conf.RetryLogger = "not a logger"
+
+	if _, err := conf.Client(); err == nil {
+		t.Fatal("Expected an error for an invalid logger, got nil")
+	}
+}
+
+func TestRetryLogger_RemainingRetries(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Override *RetryOverride
+		Expected string
+	}{
+		{Name: "HTTPRetryMax", Expected: "(3 left)"},
+		{Name: "override", Override: &RetryOverride{Max: 6}, Expected: "(6 left)"},
+		{Name: "override below HTTPRetryMax", Override: &RetryOverride{Max: 1}, Expected: "(3 left)"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			logger := &recordingLogger{}
+			conf := testConfig(server.URL)
+			conf.RetryLogger = logger
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ctx := context.Background()
+			if tc.Override != nil {
+				ctx = WithRetryOverride(ctx, *tc.Override)
+			}
+			req, err := client.GodoClient().NewRequest(ctx, http.MethodGet, "/v2/account", nil)
+			if err != nil {
+				t.Fatalf("unable to build request: %s", err)
+			}
+			if _, err := client.GodoClient().Do(ctx, req, nil); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			logged := strings.Join(logger.messages, "\n")
+			if !strings.Contains(logged, tc.Expected) {
+				t.Fatalf("Expected the retry to be logged with %q, got %q", tc.Expected, logged)
+			}
+		})
+	}
+}
+
+// recordingLeveledLogger records the key-value pairs logged to it.
+type recordingLeveledLogger struct {
+	mu            sync.Mutex
+	keysAndValues [][]interface{}
+}
+
+func (l *recordingLeveledLogger) record(keysAndValues []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keysAndValues = append(l.keysAndValues, keysAndValues)
+}
+
+func (l *recordingLeveledLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.record(keysAndValues)
+}
+func (l *recordingLeveledLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.record(keysAndValues)
+}
+func (l *recordingLeveledLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.record(keysAndValues)
+}
+func (l *recordingLeveledLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.record(keysAndValues)
+}
+
+func TestRetryLogger_Environment(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	leveled := &recordingLeveledLogger{}
+
+	for _, retryLogger := range []interface{}{logger, leveled} {
+		atomic.StoreInt32(&attempts, 0)
+
+		conf := testConfig(server.URL)
+		conf.RetryLogger = retryLogger
+		conf.Environment = "staging"
+
+		client, err := conf.Client()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if len(logger.messages) == 0 {
+		t.Fatal("Expected messages to be logged")
+	}
+	for _, message := range logger.messages {
+		if !strings.HasPrefix(message, "[") || !strings.HasSuffix(message, "(environment: staging)") {
+			t.Fatalf("Expected the environment after the message, got %q", message)
+		}
+	}
+
+	if len(leveled.keysAndValues) == 0 {
+		t.Fatal("Expected messages to be logged")
+	}
+	for _, keysAndValues := range leveled.keysAndValues {
+		n := len(keysAndValues)
+		if n < 2 || keysAndValues[n-2] != "environment" || keysAndValues[n-1] != "staging" {
+			t.Fatalf("Expected the environment in the key-value pairs, got %v", keysAndValues)
+		}
+	}
+}
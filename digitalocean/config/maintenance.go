@@ -0,0 +1,46 @@
+package config
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaintenanceMaxWait caps the wait for a maintenance window when
+// MaintenanceMaxWait is unset.
+const defaultMaintenanceMaxWait = 5 * time.Minute
+
+// isMaintenanceResponse reports whether resp is a 503 whose DigitalOcean
+// error body indicates a maintenance window.
+func isMaintenanceResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+
+	apiErr := parseAPIError(resp)
+	if apiErr == nil {
+		return false
+	}
+
+	return strings.Contains(strings.ToLower(apiErr.ID), "maintenance") ||
+		strings.Contains(strings.ToLower(apiErr.Message), "maintenance")
+}
+
+// maintenanceETA returns when the maintenance window is expected to end,
+// based on the Retry-After header in either its seconds or HTTP date form.
+func maintenanceETA(resp *http.Response, now time.Time) (time.Time, bool) {
+	retryAfter := resp.Header.Get(headerRetryAfter)
+	if retryAfter == "" {
+		return time.Time{}, false
+	}
+
+	if seconds, err := strconv.ParseInt(retryAfter, 10, 64); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if eta, err := http.ParseTime(retryAfter); err == nil {
+		return eta, true
+	}
+
+	return time.Time{}, false
+}
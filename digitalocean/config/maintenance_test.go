@@ -0,0 +1,110 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const maintenanceBody = `{"id":"service_unavailable","message":"The API is undergoing scheduled maintenance."}`
+
+func TestIsMaintenanceResponse(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Status   int
+		Body     string
+		Expected bool
+	}{
+		{Name: "maintenance", Status: http.StatusServiceUnavailable, Body: maintenanceBody, Expected: true},
+		{Name: "maintenance id", Status: http.StatusServiceUnavailable, Body: `{"id":"maintenance","message":"back soon"}`, Expected: true},
+		{Name: "overloaded", Status: http.StatusServiceUnavailable, Body: `{"id":"service_unavailable","message":"Server is overloaded."}`},
+		{Name: "other status", Status: http.StatusInternalServerError, Body: maintenanceBody},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			resp := testResponse(tc.Status, nil)
+			resp.Body = io.NopCloser(strings.NewReader(tc.Body))
+			if got := isMaintenanceResponse(resp); got != tc.Expected {
+				t.Fatalf("Expected %t, got %t", tc.Expected, got)
+			}
+		})
+	}
+}
+
+func TestBackoff_MaintenanceWait(t *testing.T) {
+	var notified time.Time
+	policy := newBackoffPolicy(&Config{
+		MaintenanceMaxWait: time.Minute,
+		OnMaintenance:      func(eta time.Time) { notified = eta },
+	})
+
+	cases := []struct {
+		Name     string
+		ETA      time.Duration
+		Expected time.Duration
+	}{
+		{Name: "within cap", ETA: 20 * time.Second, Expected: 20 * time.Second},
+		{Name: "capped", ETA: 10 * time.Minute, Expected: time.Minute},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			notified = time.Time{}
+			state := &requestState{maintenanceUntil: time.Now().Add(tc.ETA)}
+			req, _ := http.NewRequestWithContext(context.WithValue(context.Background(), requestStateKey{}, state), http.MethodGet, "https://api.digitalocean.com/v2/account", nil)
+			resp := testResponse(http.StatusServiceUnavailable, nil)
+			resp.Request = req
+
+			sleep := policy.Backoff(time.Second, 30*time.Second, 0, resp)
+			if diff := tc.Expected - sleep; diff < 0 || diff > time.Second {
+				t.Fatalf("Expected a sleep of about %s, got %s", tc.Expected, sleep)
+			}
+			if notified.IsZero() {
+				t.Fatal("Expected OnMaintenance to be called")
+			}
+		})
+	}
+}
+
+func TestMaintenance_WaitsBeforeRetrying(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set(headerRetryAfter, "60")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, maintenanceBody)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var eta time.Time
+	conf := testConfig(server.URL)
+	conf.MaintenanceMaxWait = 100 * time.Millisecond
+	conf.OnMaintenance = func(t time.Time) { eta = t }
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	start := time.Now()
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	elapsed := time.Since(start)
+
+	if elapsed < 100*time.Millisecond {
+		t.Fatalf("Expected to wait for the capped maintenance window, took %s", elapsed)
+	}
+	if until := time.Until(eta); until < 50*time.Second {
+		t.Fatalf("Expected OnMaintenance to report the advertised ETA, got %s", eta)
+	}
+}
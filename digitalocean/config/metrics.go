@@ -0,0 +1,35 @@
+package config
+
+import (
+	"net/http"
+	"time"
+)
+
+// MetricsSink receives the activity of a client, e.g. to export it to a
+// monitoring system. Its methods are called concurrently and must not block.
+// See the configprom package for a Prometheus implementation.
+type MetricsSink interface {
+	// RequestStarted is called once for every request, before its first
+	// attempt. resourceType classifies the request as configured by
+	// ResourceTypes.
+	RequestStarted(resourceType string)
+
+	// Retried is called before every retried attempt.
+	Retried()
+
+	// RateLimited is called for every 429 Too Many Requests response.
+	RateLimited()
+
+	// BackedOff is called with every wait scheduled before a retry.
+	BackedOff(sleep time.Duration)
+}
+
+// reportBackoff wraps backoff so that every wait it computes is reported to
+// sink.
+func reportBackoff(sink MetricsSink, backoff func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration) func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	return func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		sleep := backoff(min, max, attemptNum, resp)
+		sink.BackedOff(sleep)
+		return sleep
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeMetricsSink records the calls made to it.
+type fakeMetricsSink struct {
+	mu          sync.Mutex
+	requests    []string
+	retries     int
+	rateLimited int
+	backoffs    []time.Duration
+}
+
+func (s *fakeMetricsSink) RequestStarted(resourceType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, resourceType)
+}
+
+func (s *fakeMetricsSink) Retried() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries++
+}
+
+func (s *fakeMetricsSink) RateLimited() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited++
+}
+
+func (s *fakeMetricsSink) BackedOff(sleep time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backoffs = append(s.backoffs, sleep)
+}
+
+func TestClient_Metrics(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	sink := &fakeMetricsSink{}
+	c := testConfig(server.URL)
+	c.Metrics = sink
+	c.BackoffSchedule = []time.Duration{5 * time.Millisecond}
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, path := range []string{"/v2/droplets", "/v2/kubernetes/clusters"} {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, path); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if expected := []string{"droplets", "kubernetes"}; !reflect.DeepEqual(sink.requests, expected) {
+		t.Fatalf("Expected requests to %v, got %v", expected, sink.requests)
+	}
+	if sink.retries != 1 || sink.rateLimited != 1 {
+		t.Fatalf("Expected 1 retry and 1 rate limited response, got %d and %d", sink.retries, sink.rateLimited)
+	}
+	if expected := []time.Duration{5 * time.Millisecond}; !reflect.DeepEqual(sink.backoffs, expected) {
+		t.Fatalf("Expected backoffs of %v, got %v", expected, sink.backoffs)
+	}
+}
+
+// environmentMetricsSink is a fakeMetricsSink recording the environment of
+// every request.
+type environmentMetricsSink struct {
+	*fakeMetricsSink
+	environment  string
+	environments *[]string
+}
+
+func (s *environmentMetricsSink) WithEnvironment(environment string) MetricsSink {
+	labeled := *s
+	labeled.environment = environment
+	return &labeled
+}
+
+func (s *environmentMetricsSink) RequestStarted(resourceType string) {
+	s.fakeMetricsSink.RequestStarted(resourceType)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.environments = append(*s.environments, s.environment)
+}
+
+func TestClient_MetricsEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var environments []string
+	c := testConfig(server.URL)
+	c.Environment = "staging"
+	c.Metrics = &environmentMetricsSink{fakeMetricsSink: &fakeMetricsSink{}, environments: &environments}
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/droplets"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := []string{"staging"}; !reflect.DeepEqual(environments, expected) {
+		t.Fatalf("Expected requests labeled %v, got %v", expected, environments)
+	}
+}
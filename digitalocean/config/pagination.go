@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/digitalocean/godo"
+)
+
+// ListFunc retrieves a single page of a paginated godo list endpoint, e.g.
+// a closure around client.Droplets.List.
+type ListFunc[T any] func(ctx context.Context, opts *godo.ListOptions) ([]T, *godo.Response, error)
+
+// ListAll retrieves every page of a list endpoint, one page at a time.
+func ListAll[T any](ctx context.Context, perPage int, list ListFunc[T]) ([]T, error) {
+	return listFrom(ctx, 1, perPage, list)
+}
+
+// listFrom serially retrieves the pages of a list endpoint starting at page.
+func listFrom[T any](ctx context.Context, page, perPage int, list ListFunc[T]) ([]T, error) {
+	opts := &godo.ListOptions{
+		Page:    page,
+		PerPage: perPage,
+	}
+
+	var all []T
+	for {
+		items, resp, err := list(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return all, nil
+		}
+
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, err
+		}
+		opts.Page = page + 1
+	}
+}
+
+// ListAllConcurrent retrieves every page of a list endpoint, fetching up to
+// prefetch pages at once. Items are returned in page order. The first error
+// cancels any outstanding page requests and is returned. Requests still go
+// through the client's rate limiting, so prefetching never exceeds it.
+//
+// The total number of pages is taken from the first page's "last" link. When
+// the endpoint does not provide one, pages are fetched serially.
+func ListAllConcurrent[T any](ctx context.Context, perPage, prefetch int, list ListFunc[T]) ([]T, error) {
+	if prefetch <= 1 {
+		return ListAll(ctx, perPage, list)
+	}
+
+	first, resp, err := list(ctx, &godo.ListOptions{Page: 1, PerPage: perPage})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+		return first, nil
+	}
+
+	lastPage, ok := lastPageNumber(resp.Links)
+	if !ok {
+		rest, err := listFrom(ctx, 2, perPage, list)
+		if err != nil {
+			return nil, err
+		}
+		return append(first, rest...), nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([][]T, lastPage+1)
+	pages[1] = first
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, prefetch)
+
+	for page := 2; page <= lastPage; page++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			items, _, err := list(ctx, &godo.ListOptions{Page: page, PerPage: perPage})
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			pages[page] = items
+		}(page)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var all []T
+	for _, items := range pages {
+		all = append(all, items...)
+	}
+	return all, nil
+}
+
+// lastPageNumber extracts the page number of the "last" pagination link.
+func lastPageNumber(links *godo.Links) (int, bool) {
+	if links.Pages == nil || links.Pages.Last == "" {
+		return 0, false
+	}
+
+	u, err := url.Parse(links.Pages.Last)
+	if err != nil {
+		return 0, false
+	}
+
+	page, err := strconv.Atoi(u.Query().Get("page"))
+	if err != nil || page < 1 {
+		return 0, false
+	}
+
+	return page, true
+}
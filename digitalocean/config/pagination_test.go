@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// fakeLister serves items in pages, optionally failing on one page.
+type fakeLister struct {
+	items    []int
+	failPage int
+	noLast   bool
+
+	inFlight    int32
+	maxInFlight int32
+	mu          sync.Mutex
+	requested   []int
+}
+
+func (f *fakeLister) list(ctx context.Context, opts *godo.ListOptions) ([]int, *godo.Response, error) {
+	current := atomic.AddInt32(&f.inFlight, 1)
+	defer atomic.AddInt32(&f.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(&f.maxInFlight)
+		if current <= max || atomic.CompareAndSwapInt32(&f.maxInFlight, max, current) {
+			break
+		}
+	}
+
+	f.mu.Lock()
+	f.requested = append(f.requested, opts.Page)
+	f.mu.Unlock()
+
+	// Make later pages faster so out-of-order completion is likely.
+	time.Sleep(time.Duration(20-opts.Page) * time.Millisecond)
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if opts.Page == f.failPage {
+		return nil, nil, fmt.Errorf("page %d failed", opts.Page)
+	}
+
+	lastPage := (len(f.items) + opts.PerPage - 1) / opts.PerPage
+	start := (opts.Page - 1) * opts.PerPage
+	end := start + opts.PerPage
+	if end > len(f.items) {
+		end = len(f.items)
+	}
+
+	pages := &godo.Pages{}
+	if opts.Page > 1 {
+		pages.Prev = fmt.Sprintf("https://api.digitalocean.com/v2/droplets?page=%d", opts.Page-1)
+	}
+	if opts.Page < lastPage {
+		pages.Next = fmt.Sprintf("https://api.digitalocean.com/v2/droplets?page=%d", opts.Page+1)
+		if !f.noLast {
+			pages.Last = fmt.Sprintf("https://api.digitalocean.com/v2/droplets?page=%d", lastPage)
+		}
+	}
+
+	return f.items[start:end], &godo.Response{Links: &godo.Links{Pages: pages}}, nil
+}
+
+func sequence(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+func TestListAllConcurrent_Ordering(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Prefetch int
+		NoLast   bool
+	}{
+		{Name: "serial", Prefetch: 1},
+		{Name: "prefetch", Prefetch: 3},
+		{Name: "no last link", Prefetch: 3, NoLast: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			lister := &fakeLister{items: sequence(95), noLast: tc.NoLast}
+
+			items, err := ListAllConcurrent(context.Background(), 10, tc.Prefetch, lister.list)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(items, lister.items) {
+				t.Fatalf("Expected items in page order, got %v", items)
+			}
+			if len(lister.requested) != 10 {
+				t.Fatalf("Expected 10 pages to be requested, got %v", lister.requested)
+			}
+			if lister.maxInFlight > int32(tc.Prefetch) {
+				t.Fatalf("Expected at most %d requests in flight, got %d", tc.Prefetch, lister.maxInFlight)
+			}
+		})
+	}
+}
+
+func TestListAllConcurrent_Error(t *testing.T) {
+	lister := &fakeLister{items: sequence(200), failPage: 3}
+
+	_, err := ListAllConcurrent(context.Background(), 10, 2, lister.list)
+	if err == nil || err.Error() != "page 3 failed" {
+		t.Fatalf("Expected the page error, got %v", err)
+	}
+
+	// The remaining pages must not all be fetched once a page has failed.
+	if len(lister.requested) >= 20 {
+		t.Fatalf("Expected early termination, got %d pages requested", len(lister.requested))
+	}
+}
+
+func TestListAllConcurrent_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	lister := &fakeLister{items: sequence(50)}
+	if _, err := ListAllConcurrent(ctx, 10, 2, lister.list); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// RateLimitPolicy is the structure of a rate limit policy file. Fields that
+// are omitted leave the corresponding Config values untouched.
+type RateLimitPolicy struct {
+	RequestsPerSecond *float64           `json:"requests_per_second"`
+	Burst             *int               `json:"burst"`
+	Algorithm         *ThrottleAlgorithm `json:"algorithm"`
+	Services          map[string]float64 `json:"services"`
+	Methods           map[string]float64 `json:"methods"`
+	Backoff           *BackoffPolicy     `json:"backoff"`
+}
+
+// BackoffPolicy is the retry and backoff section of a RateLimitPolicy. Wait
+// times are expressed in seconds.
+type BackoffPolicy struct {
+	Strategy   *BackoffStrategy `json:"strategy"`
+	MaxRetries *int             `json:"max_retries"`
+	WaitMin    *float64         `json:"wait_min"`
+	WaitMax    *float64         `json:"wait_max"`
+}
+
+// LoadRateLimitPolicy reads a JSON rate limit policy from path and applies
+// it to c. For example:
+//
+//	{
+//	  "requests_per_second": 10,
+//	  "burst": 5,
+//	  "algorithm": "token_bucket",
+//	  "services": {"droplets": 2, "databases": 1},
+//	  "methods": {"POST": 1},
+//	  "backoff": {"strategy": "exponential", "max_retries": 5, "wait_min": 1, "wait_max": 30}
+//	}
+func (c *Config) LoadRateLimitPolicy(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read rate limit policy: %s", err)
+	}
+
+	policy := RateLimitPolicy{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return fmt.Errorf("invalid rate limit policy %s: %s", path, err)
+	}
+
+	if err := policy.validate(); err != nil {
+		return fmt.Errorf("invalid rate limit policy %s: %s", path, err)
+	}
+
+	policy.apply(c)
+	return nil
+}
+
+func (p *RateLimitPolicy) validate() error {
+	if p.RequestsPerSecond != nil && *p.RequestsPerSecond < 0 {
+		return fmt.Errorf("requests_per_second must not be negative")
+	}
+	if p.Burst != nil && *p.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	if p.Algorithm != nil {
+		switch *p.Algorithm {
+		case TokenBucket, LeakyBucket:
+		default:
+			return fmt.Errorf("unknown algorithm %q, expected %q or %q", *p.Algorithm, TokenBucket, LeakyBucket)
+		}
+	}
+	for service, limit := range p.Services {
+		if limit < 0 {
+			return fmt.Errorf("limit for service %q must not be negative", service)
+		}
+	}
+	for method, limit := range p.Methods {
+		if !isHTTPMethod(method) {
+			return fmt.Errorf("unknown HTTP method %q", method)
+		}
+		if limit < 0 {
+			return fmt.Errorf("limit for method %q must not be negative", method)
+		}
+	}
+
+	if b := p.Backoff; b != nil {
+		if b.Strategy != nil {
+			switch *b.Strategy {
+			case ExponentialBackoff, LinearJitterBackoff:
+			default:
+				return fmt.Errorf("unknown backoff strategy %q, expected %q or %q", *b.Strategy, ExponentialBackoff, LinearJitterBackoff)
+			}
+		}
+		if b.MaxRetries != nil && *b.MaxRetries < 0 {
+			return fmt.Errorf("backoff max_retries must not be negative")
+		}
+		if b.WaitMin != nil && *b.WaitMin < 0 {
+			return fmt.Errorf("backoff wait_min must not be negative")
+		}
+		if b.WaitMax != nil && *b.WaitMax < 0 {
+			return fmt.Errorf("backoff wait_max must not be negative")
+		}
+		if b.WaitMin != nil && b.WaitMax != nil && *b.WaitMin > *b.WaitMax {
+			return fmt.Errorf("backoff wait_min must not exceed wait_max")
+		}
+	}
+
+	return nil
+}
+
+func (p *RateLimitPolicy) apply(c *Config) {
+	if p.RequestsPerSecond != nil {
+		c.RequestsPerSecond = *p.RequestsPerSecond
+	}
+	if p.Burst != nil {
+		c.RequestsBurst = *p.Burst
+	}
+	if p.Algorithm != nil {
+		c.ThrottleAlgorithm = *p.Algorithm
+	}
+	if p.Services != nil {
+		c.ServiceRequestsPerSecond = p.Services
+	}
+	if p.Methods != nil {
+		methods := make(map[string]float64, len(p.Methods))
+		for method, limit := range p.Methods {
+			methods[strings.ToUpper(method)] = limit
+		}
+		c.MethodRequestsPerSecond = methods
+	}
+
+	if b := p.Backoff; b != nil {
+		if b.Strategy != nil {
+			c.BackoffStrategy = *b.Strategy
+		}
+		if b.MaxRetries != nil {
+			c.HTTPRetryMax = *b.MaxRetries
+		}
+		if b.WaitMin != nil {
+			c.HTTPRetryWaitMin = *b.WaitMin
+		}
+		if b.WaitMax != nil {
+			c.HTTPRetryWaitMax = *b.WaitMax
+		}
+	}
+}
+
+// isHTTPMethod reports whether method is a standard HTTP method name.
+func isHTTPMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
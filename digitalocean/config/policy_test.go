@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePolicy(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("unable to write policy: %s", err)
+	}
+	return path
+}
+
+func TestLoadRateLimitPolicy(t *testing.T) {
+	path := writePolicy(t, `{
+		"requests_per_second": 10,
+		"burst": 5,
+		"algorithm": "leaky_bucket",
+		"services": {"droplets": 2, "databases": 1},
+		"methods": {"post": 1},
+		"backoff": {"strategy": "linear_jitter", "max_retries": 6, "wait_min": 2, "wait_max": 20}
+	}`)
+
+	conf := testConfig("https://api.digitalocean.com")
+	if err := conf.LoadRateLimitPolicy(path); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if conf.RequestsPerSecond != 10 || conf.RequestsBurst != 5 || conf.ThrottleAlgorithm != LeakyBucket {
+		t.Errorf("Unexpected throttle settings: %v, %v, %v", conf.RequestsPerSecond, conf.RequestsBurst, conf.ThrottleAlgorithm)
+	}
+	if conf.ServiceRequestsPerSecond["droplets"] != 2 || conf.ServiceRequestsPerSecond["databases"] != 1 {
+		t.Errorf("Unexpected service limits: %v", conf.ServiceRequestsPerSecond)
+	}
+	if conf.MethodRequestsPerSecond["POST"] != 1 {
+		t.Errorf("Unexpected method limits: %v", conf.MethodRequestsPerSecond)
+	}
+	if conf.BackoffStrategy != LinearJitterBackoff || conf.HTTPRetryMax != 6 ||
+		conf.HTTPRetryWaitMin != 2 || conf.HTTPRetryWaitMax != 20 {
+		t.Errorf("Unexpected backoff settings: %v, %v, %v, %v", conf.BackoffStrategy, conf.HTTPRetryMax, conf.HTTPRetryWaitMin, conf.HTTPRetryWaitMax)
+	}
+
+	if _, err := conf.Client(); err != nil {
+		t.Fatalf("Expected the policy to produce a valid client, got %s", err)
+	}
+}
+
+func TestLoadRateLimitPolicy_Partial(t *testing.T) {
+	path := writePolicy(t, `{"services": {"droplets": 2}}`)
+
+	conf := testConfig("https://api.digitalocean.com")
+	conf.RequestsPerSecond = 3
+	if err := conf.LoadRateLimitPolicy(path); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if conf.RequestsPerSecond != 3 || conf.HTTPRetryMax != 3 {
+		t.Fatalf("Expected omitted fields to be left untouched, got %v and %v", conf.RequestsPerSecond, conf.HTTPRetryMax)
+	}
+}
+
+func TestLoadRateLimitPolicy_Invalid(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Contents string
+		Error    string
+	}{
+		{Name: "malformed", Contents: `{"requests_per_second":`, Error: "unexpected EOF"},
+		{Name: "unknown field", Contents: `{"requests_per_minute": 10}`, Error: "requests_per_minute"},
+		{Name: "negative rate", Contents: `{"requests_per_second": -1}`, Error: "requests_per_second must not be negative"},
+		{Name: "unknown algorithm", Contents: `{"algorithm": "fifo"}`, Error: "unknown algorithm"},
+		{Name: "unknown method", Contents: `{"methods": {"FETCH": 1}}`, Error: "unknown HTTP method"},
+		{Name: "unknown strategy", Contents: `{"backoff": {"strategy": "random"}}`, Error: "unknown backoff strategy"},
+		{Name: "inverted waits", Contents: `{"backoff": {"wait_min": 10, "wait_max": 1}}`, Error: "wait_min must not exceed wait_max"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			conf := testConfig("https://api.digitalocean.com")
+			err := conf.LoadRateLimitPolicy(writePolicy(t, tc.Contents))
+			if err == nil || !strings.Contains(err.Error(), tc.Error) {
+				t.Fatalf("Expected an error containing %q, got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestLoadRateLimitPolicy_MissingFile(t *testing.T) {
+	conf := testConfig("https://api.digitalocean.com")
+	if err := conf.LoadRateLimitPolicy(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
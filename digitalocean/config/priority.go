@@ -0,0 +1,147 @@
+package config
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Priority orders requests waiting on the client-side rate limit when
+// PriorityScheduling is enabled. Higher priorities are dispatched first.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose requests are scheduled with priority.
+// Requests default to PriorityNormal.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// priorityFrom returns the priority attached to ctx.
+func priorityFrom(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityNormal
+}
+
+// priorityScheduler lets a single request at a time wait on the rate
+// limiter, handing the turn to the highest priority request queued. Among
+// equal priorities, requests are served in order of their fair queuing
+// finish tag when fair is set, then in order of arrival.
+type priorityScheduler struct {
+	fair *fairQueue
+
+	mu      sync.Mutex
+	busy    bool
+	seq     uint64
+	waiters priorityQueue
+}
+
+// acquire blocks until it is the turn of the request or ctx is done. A
+// successful acquire must be followed by release.
+func (s *priorityScheduler) acquire(ctx context.Context, priority Priority, workspace string, cost int) error {
+	s.mu.Lock()
+	var finish float64
+	if s.fair != nil {
+		finish = s.fair.tag(workspace, cost)
+	}
+	if !s.busy {
+		s.busy = true
+		if s.fair != nil {
+			s.fair.dispatched(finish)
+		}
+		s.mu.Unlock()
+		return nil
+	}
+
+	w := &priorityWaiter{priority: priority, finish: finish, seq: s.seq, ready: make(chan struct{})}
+	s.seq++
+	heap.Push(&s.waiters, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.index >= 0 {
+			heap.Remove(&s.waiters, w.index)
+			s.mu.Unlock()
+			return ctx.Err()
+		}
+		s.mu.Unlock()
+
+		// The turn was handed over concurrently; pass it on.
+		s.release()
+		return ctx.Err()
+	}
+}
+
+// release hands the turn to the next request queued, if any.
+func (s *priorityScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiters.Len() == 0 {
+		s.busy = false
+		return
+	}
+	w := heap.Pop(&s.waiters).(*priorityWaiter)
+	if s.fair != nil {
+		s.fair.dispatched(w.finish)
+	}
+	close(w.ready)
+}
+
+type priorityWaiter struct {
+	priority Priority
+	finish   float64
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// priorityQueue implements heap.Interface over waiting requests.
+type priorityQueue []*priorityWaiter
+
+func (q priorityQueue) Len() int { return len(q) }
+
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	if q[i].finish != q[j].finish {
+		return q[i].finish < q[j].finish
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q priorityQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *priorityQueue) Push(x any) {
+	w := x.(*priorityWaiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottleTransport_PriorityScheduling(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.URL.Query().Get("name"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.RequestsPerSecond = 10
+	c.PriorityScheduling = true
+
+	transport, err := newThrottleTransport(c, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	send := func(name string, priority Priority) {
+		ctx := WithPriority(context.Background(), priority)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v2/account?name="+name, nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+		resp.Body.Close()
+	}
+
+	// Take the only token, then queue requests behind one that holds the
+	// turn while it waits for the next token.
+	send("first", PriorityNormal)
+
+	var wg sync.WaitGroup
+	queue := []struct {
+		Name     string
+		Priority Priority
+	}{
+		{"holder", PriorityNormal},
+		{"low-1", PriorityLow},
+		{"normal", PriorityNormal},
+		{"low-2", PriorityLow},
+		{"high-1", PriorityHigh},
+		{"high-2", PriorityHigh},
+	}
+	for _, r := range queue {
+		wg.Add(1)
+		go func(name string, priority Priority) {
+			defer wg.Done()
+			send(name, priority)
+		}(r.Name, r.Priority)
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	expected := []string{"first", "holder", "high-1", "high-2", "normal", "low-1", "low-2"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %d requests, got %v", len(expected), order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestPriorityScheduler_Canceled(t *testing.T) {
+	s := &priorityScheduler{}
+	if err := s.acquire(context.Background(), PriorityNormal, "", 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() { errCh <- s.acquire(ctx, PriorityHigh, "", 1) }()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+
+	if err := <-errCh; err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// The canceled request must not keep the turn from the next one.
+	s.release()
+	if err := s.acquire(context.Background(), PriorityLow, "", 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.waiters.Len() != 0 {
+		t.Fatalf("Expected no waiters left, got %d", s.waiters.Len())
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// probePath is the lightweight endpoint requested after a long backoff,
+	// relative to the API endpoint.
+	probePath = "v2/account"
+
+	defaultLongBackoffThreshold = 30 * time.Second
+)
+
+// probeTransport sends a probe request ahead of an attempt that follows a
+// long backoff, as flagged by the backoff policy on the request state. When
+// the probe fails, its response is returned in place of the real attempt,
+// attributed to the real request, so the retry policy backs off again
+// without re-sending the real request. It
+// sits below the retrying transport and sees every attempt.
+type probeTransport struct {
+	base     http.RoundTripper
+	endpoint *url.URL
+}
+
+func (t *probeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	state := requestStateFrom(req.Context())
+	if state == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	state.mu.Lock()
+	probe := state.probe
+	state.probe = false
+	state.mu.Unlock()
+
+	if !probe {
+		return t.base.RoundTrip(req)
+	}
+
+	// Like godo, resolve the probe against the endpoint, which may have a
+	// base path.
+	probeURL := t.endpoint.ResolveReference(&url.URL{Path: probePath})
+
+	probeReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, probeURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range []string{"Authorization", "User-Agent"} {
+		if v := req.Header.Get(header); v != "" {
+			probeReq.Header.Set(header, v)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(probeReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Errors reported once retries are exhausted must name the real
+		// request rather than the probe.
+		resp.Request = req
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_ProbeAfterLongBackoff(t *testing.T) {
+	cases := []struct {
+		Name             string
+		ProbeFailures    int32
+		ExpectedProbes   int32
+		ExpectedRequests int32
+	}{
+		{
+			Name:             "probe succeeds",
+			ProbeFailures:    0,
+			ExpectedProbes:   1,
+			ExpectedRequests: 2,
+		},
+		{
+			Name:             "probe still throttled",
+			ProbeFailures:    1,
+			ExpectedProbes:   2,
+			ExpectedRequests: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var probes, requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/"+probePath {
+					if r.Header.Get("Authorization") != "Bearer test-token" {
+						t.Errorf("Expected the probe to be authorized, got %q", r.Header.Get("Authorization"))
+					}
+					if atomic.AddInt32(&probes, 1) <= tc.ProbeFailures {
+						w.WriteHeader(http.StatusTooManyRequests)
+						return
+					}
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+
+				if atomic.AddInt32(&requests, 1) == 1 {
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.ProbeAfterLongBackoff = true
+			c.LongBackoffThreshold = time.Nanosecond
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			resp, err := doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets")
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			}
+			if atomic.LoadInt32(&probes) != tc.ExpectedProbes {
+				t.Fatalf("Expected %d probes, got %d", tc.ExpectedProbes, probes)
+			}
+			if atomic.LoadInt32(&requests) != tc.ExpectedRequests {
+				t.Fatalf("Expected %d requests, got %d", tc.ExpectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestClient_FailedProbeNamesRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.ProbeAfterLongBackoff = true
+	c.LongBackoffThreshold = time.Nanosecond
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	_, err = doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets")
+	if err == nil {
+		t.Fatal("Expected an error, got none")
+	}
+	if expected := "POST " + server.URL + "/v2/droplets giving up"; !strings.Contains(err.Error(), expected) {
+		t.Fatalf("Expected the error to contain %q, got %q", expected, err)
+	}
+}
+
+func TestClient_ProbeWithBasePath(t *testing.T) {
+	var probes, requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/do/v2/account":
+			atomic.AddInt32(&probes, 1)
+		case "/do/v2/droplets":
+			if atomic.AddInt32(&requests, 1) == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		default:
+			t.Errorf("Unexpected request for %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL + "/do/")
+	c.ProbeAfterLongBackoff = true
+	c.LongBackoffThreshold = time.Nanosecond
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "v2/droplets"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if atomic.LoadInt32(&probes) != 1 {
+		t.Fatalf("Expected 1 probe under the base path, got %d", probes)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", requests)
+	}
+}
+
+func TestClient_NoProbeAfterShortBackoff(t *testing.T) {
+	var probes, requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+probePath {
+			atomic.AddInt32(&probes, 1)
+		} else if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.ProbeAfterLongBackoff = true
+	c.LongBackoffThreshold = time.Hour
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/droplets"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if atomic.LoadInt32(&probes) != 0 {
+		t.Fatalf("Expected no probes, got %d", probes)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", requests)
+	}
+}
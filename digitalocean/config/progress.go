@@ -0,0 +1,95 @@
+package config
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProgressSnapshot reports the cumulative activity of a client.
+type ProgressSnapshot struct {
+	// Requests is the number of requests sent, not counting retries.
+	Requests int64
+	// Retries is the number of retried attempts.
+	Retries int64
+	// Backoff is the total time scheduled to wait between retries.
+	Backoff time.Duration
+}
+
+// progressCounters accumulates the counters reported in a ProgressSnapshot
+// and a RunSummary.
+type progressCounters struct {
+	requests    int64
+	retries     int64
+	backoff     int64
+	rateLimited int64
+	bytes       int64
+}
+
+// attempted counts an attempt reported by retryablehttp.RequestLogHook.
+func (p *progressCounters) attempted(attempt int) {
+	if attempt == 0 {
+		atomic.AddInt64(&p.requests, 1)
+	} else {
+		atomic.AddInt64(&p.retries, 1)
+	}
+}
+
+// countBackoff wraps backoff so that the waits it returns are accumulated.
+func (p *progressCounters) countBackoff(backoff func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration) func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	return func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		sleep := backoff(min, max, attemptNum, resp)
+		atomic.AddInt64(&p.backoff, int64(sleep))
+		return sleep
+	}
+}
+
+func (p *progressCounters) snapshot() ProgressSnapshot {
+	return ProgressSnapshot{
+		Requests: atomic.LoadInt64(&p.requests),
+		Retries:  atomic.LoadInt64(&p.retries),
+		Backoff:  time.Duration(atomic.LoadInt64(&p.backoff)),
+	}
+}
+
+// progressReporter periodically passes a snapshot of its counters to a
+// callback until it is stopped.
+type progressReporter struct {
+	counters *progressCounters
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func startProgressReporter(counters *progressCounters, interval time.Duration, onProgress func(ProgressSnapshot)) *progressReporter {
+	r := &progressReporter{
+		counters: counters,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				onProgress(counters.snapshot())
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+
+	return r
+}
+
+// close stops the reporter and waits for a callback in progress to return.
+func (r *progressReporter) close() {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+}
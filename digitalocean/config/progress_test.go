@@ -0,0 +1,80 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_OnProgress(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	goroutines := runtime.NumGoroutine()
+
+	var mu sync.Mutex
+	var snapshots []ProgressSnapshot
+	conf := testConfig(server.URL)
+	conf.ProgressInterval = 10 * time.Millisecond
+	conf.OnProgress = func(s ProgressSnapshot) {
+		mu.Lock()
+		snapshots = append(snapshots, s)
+		mu.Unlock()
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := client.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("unexpected error on second Close: %s", err)
+	}
+
+	mu.Lock()
+	count := len(snapshots)
+	last := snapshots[count-1]
+	mu.Unlock()
+
+	if count < 2 {
+		t.Fatalf("Expected the callback to fire periodically, got %d calls", count)
+	}
+	if last.Requests != 1 || last.Retries != 1 || last.Backoff <= 0 {
+		t.Fatalf("Expected 1 request, 1 retry and some backoff, got %+v", last)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	if len(snapshots) != count {
+		t.Fatalf("Expected no callback after Close, got %d more", len(snapshots)-count)
+	}
+	mu.Unlock()
+
+	server.CloseClientConnections()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Fatalf("Expected no leaked goroutines, got %d, started with %d", n, goroutines)
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// rateLimitTracker records the rate limit state advertised by the most recent
+// API response.
+type rateLimitTracker struct {
+	mu       sync.Mutex
+	rate     godo.Rate
+	observed bool
+
+	// onApproaching is called once per rate limit window when the remaining
+	// requests drop below approachingThreshold.
+	onApproaching        func(remaining, limit int)
+	approachingThreshold int
+	warnedUntil          time.Time
+
+	// onObserve, when set, is called with the rate limit captured from every
+	// response carrying rate limit headers.
+	onObserve func(rate godo.Rate)
+}
+
+// observe captures the rate limit headers of resp, if any.
+func (t *rateLimitTracker) observe(resp *http.Response) {
+	t.observeAt(resp, time.Now())
+}
+
+func (t *rateLimitTracker) observeAt(resp *http.Response, now time.Time) {
+	limit := resp.Header.Get(headerRateLimit)
+	remaining := resp.Header.Get(headerRateRemaining)
+	reset := resp.Header.Get(headerRateReset)
+	if limit == "" && remaining == "" && reset == "" {
+		return
+	}
+
+	t.mu.Lock()
+
+	if v, err := strconv.Atoi(limit); err == nil {
+		t.rate.Limit = v
+	}
+	if v, err := strconv.Atoi(remaining); err == nil {
+		t.rate.Remaining = v
+	}
+	if v, err := strconv.ParseInt(reset, 10, 64); err == nil && v != 0 {
+		t.rate.Reset = godo.Timestamp{Time: time.Unix(v, 0)}
+	}
+	t.observed = true
+
+	warn := t.onApproaching != nil && t.rate.Remaining < t.approachingThreshold && !now.Before(t.warnedUntil)
+	if warn {
+		// Stay quiet until the window that was current when warning resets.
+		t.warnedUntil = t.rate.Reset.Time
+		if !t.warnedUntil.After(now) {
+			t.warnedUntil = now.Add(rateLimitWindow)
+		}
+	}
+	rate := t.rate
+
+	t.mu.Unlock()
+
+	if t.onObserve != nil {
+		t.onObserve(rate)
+	}
+	if warn {
+		t.onApproaching(rate.Remaining, rate.Limit)
+	}
+}
+
+// state returns the last captured rate limit and whether any was observed.
+func (t *rateLimitTracker) state() (godo.Rate, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.rate, t.observed
+}
+
+// clear forgets the captured rate limit state.
+func (t *rateLimitTracker) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rate = godo.Rate{}
+	t.observed = false
+	t.warnedUntil = time.Time{}
+}
+
+// rateLimitTransport feeds every response, including those that are later
+// retried, to a rateLimitTracker.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	tracker *rateLimitTracker
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil {
+		t.tracker.observe(resp)
+	}
+	return resp, err
+}
+
+// ResetRateLimitTracking clears the rate limit state captured from previous
+// responses, and the request slots booked by RespectHourlyBudget. Tools that
+// know a new rate limit window has started can call it so that stale values
+// do not penalize the next requests.
+func (c *CombinedConfig) ResetRateLimitTracking() {
+	c.rateLimits.clear()
+	if c.hourlyBudget != nil {
+		c.hourlyBudget.clear()
+	}
+}
+
+// LastRate returns the rate limit advertised by the most recent API response
+// carrying rate limit headers, including responses that were retried. It is
+// the zero godo.Rate until such a response is received.
+func (c *CombinedConfig) LastRate() godo.Rate {
+	rate, _ := c.rateLimits.state()
+	return rate
+}
+
+// WaitForReset blocks until the rate limit window advertised by the most
+// recent API response resets, or ctx is done. It returns immediately when no
+// rate limit was captured, the window has already reset, or more requests
+// than ApproachingLimitThreshold remain in it.
+func (c *CombinedConfig) WaitForReset(ctx context.Context) error {
+	rate, observed := c.rateLimits.state()
+	if !observed || rate.Remaining > c.rateLimits.approachingThreshold {
+		return nil
+	}
+
+	wait := time.Until(rate.Reset.Time)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
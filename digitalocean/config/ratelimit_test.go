@@ -0,0 +1,266 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestResetRateLimitTracking(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateLimit, "5000")
+		w.Header().Set(headerRateRemaining, "42")
+		w.Header().Set(headerRateReset, resetIn(time.Minute))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	rate, observed := client.rateLimits.state()
+	if !observed {
+		t.Fatal("Expected the rate limit to be captured")
+	}
+	if rate.Limit != 5000 || rate.Remaining != 42 {
+		t.Fatalf("Expected limit 5000 and remaining 42, got %d and %d", rate.Limit, rate.Remaining)
+	}
+	if got := rate.Reset.Unix(); got < reset-1 || got > reset+1 {
+		t.Fatalf("Expected reset around %d, got %d", reset, got)
+	}
+
+	client.ResetRateLimitTracking()
+
+	rate, observed = client.rateLimits.state()
+	if observed {
+		t.Fatal("Expected the rate limit state to be cleared")
+	}
+	if rate.Limit != 0 || rate.Remaining != 0 || !rate.Reset.IsZero() {
+		t.Fatalf("Expected a zero rate limit, got %+v", rate)
+	}
+}
+
+func TestResetRateLimitTracking_HourlyBudget(t *testing.T) {
+	// The budget is exhausted for the next hour until the window is reset,
+	// after which a few requests remain until a reset a second away.
+	var reset int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateLimit, "100")
+		if atomic.LoadInt32(&reset) == 0 {
+			w.Header().Set(headerRateRemaining, "0")
+			w.Header().Set(headerRateReset, resetIn(time.Hour))
+			return
+		}
+		w.Header().Set(headerRateRemaining, "5")
+		w.Header().Set(headerRateReset, resetIn(time.Second))
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RespectHourlyBudget = true
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The second request books the slot of the third an hour away.
+	for i := 0; i < 2; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	client.ResetRateLimitTracking()
+	atomic.StoreInt32(&reset, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		req, err := client.GodoClient().NewRequest(ctx, http.MethodGet, "/v2/account", nil)
+		if err != nil {
+			t.Fatalf("unable to build request: %s", err)
+		}
+		if _, err := client.GodoClient().Do(ctx, req, nil); err != nil {
+			t.Fatalf("Expected request %d after the reset not to wait for a stale slot, got %s", i, err)
+		}
+	}
+}
+
+func TestRateLimitTracker_OnApproachingLimit(t *testing.T) {
+	type warning struct{ remaining, limit int }
+	var warnings []warning
+
+	tracker := &rateLimitTracker{
+		onApproaching: func(remaining, limit int) {
+			warnings = append(warnings, warning{remaining, limit})
+		},
+		approachingThreshold: 100,
+	}
+
+	now := time.Now()
+	reset := strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10)
+	for _, remaining := range []string{"300", "150", "99", "50", "10", "0"} {
+		tracker.observeAt(testResponse(http.StatusOK, map[string]string{
+			headerRateLimit:     "5000",
+			headerRateRemaining: remaining,
+			headerRateReset:     reset,
+		}), now)
+		now = now.Add(time.Second)
+	}
+
+	if len(warnings) != 1 || warnings[0] != (warning{99, 5000}) {
+		t.Fatalf("Expected a single warning at 99 remaining, got %v", warnings)
+	}
+
+	// The next window starts once the reset time has passed.
+	now = now.Add(10 * time.Minute)
+	nextReset := strconv.FormatInt(now.Add(time.Hour).Unix(), 10)
+	for _, remaining := range []string{"4999", "80", "20"} {
+		tracker.observeAt(testResponse(http.StatusOK, map[string]string{
+			headerRateLimit:     "5000",
+			headerRateRemaining: remaining,
+			headerRateReset:     nextReset,
+		}), now)
+	}
+
+	if len(warnings) != 2 || warnings[1] != (warning{80, 5000}) {
+		t.Fatalf("Expected a second warning at 80 remaining, got %v", warnings)
+	}
+}
+
+func TestCombinedConfig_LastRate(t *testing.T) {
+	remaining := []string{"4999", "4998"}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateLimit, "5000")
+		w.Header().Set(headerRateRemaining, remaining[requests])
+		w.Header().Set(headerRateReset, "1700000000")
+		requests++
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if rate := client.LastRate(); rate != (godo.Rate{}) {
+		t.Fatalf("Expected no rate limit before any request, got %+v", rate)
+	}
+
+	for i, expected := range []int{4999, 4998} {
+		resp, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		rate := client.LastRate()
+		if rate.Limit != 5000 || rate.Remaining != expected || rate.Reset.Unix() != 1700000000 {
+			t.Fatalf("Expected request %d to leave limit 5000, remaining %d and reset 1700000000, got %+v", i, expected, rate)
+		}
+		if rate.Limit != resp.Rate.Limit || rate.Remaining != resp.Rate.Remaining || !rate.Reset.Equal(resp.Rate.Reset) {
+			t.Fatalf("Expected the rate limit parsed by godo, %+v, got %+v", resp.Rate, rate)
+		}
+	}
+}
+
+func TestCombinedConfig_WaitForReset(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Observed    bool
+		Remaining   int
+		Reset       time.Duration
+		Cancel      time.Duration
+		ExpectErr   error
+		ExpectedMin time.Duration
+		ExpectedMax time.Duration
+	}{
+		{Name: "nothing captured", ExpectedMax: 10 * time.Millisecond},
+		{Name: "healthy quota", Observed: true, Remaining: 100, Reset: time.Hour, ExpectedMax: 10 * time.Millisecond},
+		{Name: "already reset", Observed: true, Reset: -time.Second, ExpectedMax: 10 * time.Millisecond},
+		{Name: "exhausted", Observed: true, Reset: 50 * time.Millisecond, ExpectedMin: 50 * time.Millisecond, ExpectedMax: time.Second},
+		{
+			Name:        "canceled",
+			Observed:    true,
+			Reset:       time.Hour,
+			Cancel:      20 * time.Millisecond,
+			ExpectErr:   context.Canceled,
+			ExpectedMin: 20 * time.Millisecond,
+			ExpectedMax: time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, err := testConfig("https://api.digitalocean.com").Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			client.rateLimits.mu.Lock()
+			client.rateLimits.observed = tc.Observed
+			client.rateLimits.rate = godo.Rate{
+				Limit:     5000,
+				Remaining: tc.Remaining,
+				Reset:     godo.Timestamp{Time: time.Now().Add(tc.Reset)},
+			}
+			client.rateLimits.mu.Unlock()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.Cancel > 0 {
+				time.AfterFunc(tc.Cancel, cancel)
+			}
+
+			start := time.Now()
+			err = client.WaitForReset(ctx)
+			elapsed := time.Since(start)
+
+			if err != tc.ExpectErr {
+				t.Fatalf("Expected error %v, got %v", tc.ExpectErr, err)
+			}
+			if elapsed < tc.ExpectedMin || elapsed > tc.ExpectedMax {
+				t.Fatalf("Expected to wait between %s and %s, got %s", tc.ExpectedMin, tc.ExpectedMax, elapsed)
+			}
+		})
+	}
+}
+
+func TestCombinedConfig_WaitForResetCapturedState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerRateLimit, "5000")
+		w.Header().Set(headerRateRemaining, "0")
+		w.Header().Set(headerRateReset, resetIn(time.Hour))
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := client.WaitForReset(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected to wait for the captured reset until the deadline, got %v", err)
+	}
+}
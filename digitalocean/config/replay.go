@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// recordedInteraction is a single API response captured by the recording
+// transport. Recordings are stored as one JSON object per line.
+type recordedInteraction struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// recordingTransport appends every response received from the network to a
+// recording file. Request headers, and therefore credentials, are not
+// recorded. A response that cannot be recorded is still returned, since the
+// request has already taken effect.
+type recordingTransport struct {
+	base http.RoundTripper
+	path string
+
+	mu sync.Mutex
+}
+
+// newRecordingTransport returns a recordingTransport writing to path, failing
+// when path cannot be opened for writing.
+func newRecordingTransport(base http.RoundTripper, path string) (*recordingTransport, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("unable to open recording: %w", err)
+	}
+
+	return &recordingTransport{base: base, path: path}, nil
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := bufferBody(resp)
+	if err != nil {
+		// Leave it to the transports above to decide whether the
+		// truncated response may be retried.
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), &errorReader{err: err}))
+		return resp, nil
+	}
+
+	if err := t.record(req, resp, body); err != nil {
+		log.Printf("[WARN] Unable to record response of %s %s: %s", req.Method, req.URL, err)
+	}
+
+	return resp, nil
+}
+
+func (t *recordingTransport) record(req *http.Request, resp *http.Response, body []byte) error {
+	line, err := json.Marshal(recordedInteraction{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to record response: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("unable to record response: %w", err)
+	}
+	return nil
+}
+
+// replayTransport serves responses from a recording instead of the network.
+// Requests are matched on method and URL; when the same request was recorded
+// several times, the responses are served in the order they were recorded.
+type replayTransport struct {
+	mu           sync.Mutex
+	interactions map[string][]recordedInteraction
+}
+
+func newReplayTransport(path string) (*replayTransport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording: %s", err)
+	}
+	defer f.Close()
+
+	interactions := map[string][]recordedInteraction{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var interaction recordedInteraction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("unable to parse recording %s, line %d: %s", path, line, err)
+		}
+
+		key := replayKey(interaction.Method, interaction.URL)
+		interactions[key] = append(interactions[key], interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read recording: %s", err)
+	}
+
+	return &replayTransport{interactions: interactions}, nil
+}
+
+func replayKey(method, url string) string {
+	return method + " " + url
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := replayKey(req.Method, req.URL.String())
+
+	t.mu.Lock()
+	recorded := t.interactions[key]
+	if len(recorded) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s", key)
+	}
+	interaction := recorded[0]
+	t.interactions[key] = recorded[1:]
+	t.mu.Unlock()
+
+	header := interaction.Header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Body))),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
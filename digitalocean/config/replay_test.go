@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_RecordAndReplay(t *testing.T) {
+	recording := filepath.Join(t.TempDir(), "recording.jsonl")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/account":
+			w.Write([]byte(`{"account": {"email": "sammy@example.com", "status": "active"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id": "not_found", "message": "The resource you requested could not be found."}`))
+		}
+	}))
+
+	c := testConfig(server.URL)
+	c.RecordTo = recording
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, _, err := client.GodoClient().Account.Get(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if _, _, err := client.GodoClient().Droplets.Get(context.Background(), 123); err == nil {
+		t.Fatalf("Expected the missing droplet to error")
+	}
+	server.Close()
+
+	contents, err := os.ReadFile(recording)
+	if err != nil {
+		t.Fatalf("Expected a recording, got %s", err)
+	}
+	if strings.Contains(string(contents), "test-token") {
+		t.Fatalf("Expected the token not to be recorded")
+	}
+
+	c = testConfig(server.URL)
+	c.ReplayFrom = recording
+
+	client, err = c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	account, _, err := client.GodoClient().Account.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if account.Email != "sammy@example.com" {
+		t.Fatalf("Expected the recorded account, got %s", account.Email)
+	}
+
+	_, resp, err := client.GodoClient().Droplets.Get(context.Background(), 123)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected the recorded 404, got %v", err)
+	}
+
+	if _, _, err := client.GodoClient().Droplets.Get(context.Background(), 456); err == nil ||
+		!strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("Expected an unrecorded request to fail, got %v", err)
+	}
+}
+
+func TestClient_RecordAndReplayExclusive(t *testing.T) {
+	c := testConfig("https://api.digitalocean.com")
+	c.RecordTo = "recording.jsonl"
+	c.ReplayFrom = "recording.jsonl"
+
+	if _, err := c.Client(); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
+
+func TestClient_RecordToUnwritable(t *testing.T) {
+	c := testConfig("https://api.digitalocean.com")
+	c.RecordTo = filepath.Join(t.TempDir(), "missing", "recording.jsonl")
+
+	if _, err := c.Client(); err == nil || !strings.Contains(err.Error(), "unable to open recording") {
+		t.Fatalf("Expected an error opening the recording, got %v", err)
+	}
+}
+
+func TestClient_RecordFailureKeepsResponse(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "recordings")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatalf("unable to create directory: %s", err)
+	}
+
+	c := testConfig(server.URL)
+	c.RecordTo = filepath.Join(dir, "recording.jsonl")
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	// The recording can no longer be written once its directory is gone.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("unable to remove directory: %s", err)
+	}
+
+	resp, err := doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Fatalf("Expected 1 attempt, got %d", n)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// requestState carries per-request bookkeeping shared between the transports,
+// the retry policy and the backoff of a single logical request and all of its
+// attempts.
+type requestState struct {
+	// method is the HTTP method of the request.
+	method string
+
+	mu sync.Mutex
+
+	// maintenanceUntil is set when the last attempt hit a maintenance window.
+	maintenanceUntil time.Time
+
+	// probe is set when the next attempt follows a long backoff and must be
+	// preceded by a probe request.
+	probe bool
+
+	// attempts is the number of attempts whose outcome was checked by the
+	// retry policy.
+	attempts int
+
+	// retriesByStatus counts the retries made for each status code listed in
+	// RetryLimitsByStatus, other responses and errors being counted under 0.
+	retriesByStatus map[int]int
+
+	// timeline records every attempt, for RetryTimeline.
+	timeline []Attempt
+
+	// throttleWait is the time spent waiting on the client-side limiters,
+	// for ThrottleWait.
+	throttleWait time.Duration
+}
+
+type requestStateKey struct{}
+
+// requestStateFrom returns the state attached to ctx, or nil.
+func requestStateFrom(ctx context.Context) *requestState {
+	state, _ := ctx.Value(requestStateKey{}).(*requestState)
+	return state
+}
+
+// responseState returns the state attached to the request of resp, or nil.
+func responseState(resp *http.Response) *requestState {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+	return requestStateFrom(resp.Request.Context())
+}
+
+// requestStateTransport attaches a fresh requestState to every request. It
+// must wrap the retrying transport so that all attempts share the state.
+type requestStateTransport struct {
+	base http.RoundTripper
+}
+
+func (t *requestStateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if requestStateFrom(req.Context()) != nil {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := context.WithValue(req.Context(), requestStateKey{}, &requestState{method: req.Method})
+	return t.base.RoundTrip(req.WithContext(ctx))
+}
@@ -0,0 +1,64 @@
+package config
+
+import "strings"
+
+// otherResourceType labels requests to paths no resource type matches.
+const otherResourceType = "other"
+
+// defaultResourceTypes maps API path prefixes to the resource type used to
+// label metrics. ResourceTypes on Config extends and overrides it.
+var defaultResourceTypes = map[string]string{
+	"/v2/account":        "account",
+	"/v2/apps":           "apps",
+	"/v2/cdn":            "cdn",
+	"/v2/certificates":   "certificates",
+	"/v2/databases":      "databases",
+	"/v2/domains":        "domains",
+	"/v2/droplets":       "droplets",
+	"/v2/firewalls":      "firewalls",
+	"/v2/floating_ips":   "reserved_ips",
+	"/v2/images":         "images",
+	"/v2/kubernetes":     "kubernetes",
+	"/v2/load_balancers": "load_balancers",
+	"/v2/monitoring":     "monitoring",
+	"/v2/projects":       "projects",
+	"/v2/registry":       "container_registry",
+	"/v2/reserved_ips":   "reserved_ips",
+	"/v2/snapshots":      "snapshots",
+	"/v2/tags":           "tags",
+	"/v2/volumes":        "volumes",
+	"/v2/vpcs":           "vpcs",
+}
+
+// resourceClassifier infers the resource type targeted by a request from its
+// path.
+type resourceClassifier struct {
+	types map[string]string
+}
+
+func newResourceClassifier(overrides map[string]string) *resourceClassifier {
+	types := make(map[string]string, len(defaultResourceTypes)+len(overrides))
+	for prefix, resourceType := range defaultResourceTypes {
+		types[prefix] = resourceType
+	}
+	for prefix, resourceType := range overrides {
+		types[prefix] = resourceType
+	}
+	return &resourceClassifier{types: types}
+}
+
+// classify returns the resource type of the longest prefix matching path at
+// a segment boundary, or "other" when none matches.
+func (c *resourceClassifier) classify(path string) string {
+	resourceType, matched := otherResourceType, -1
+	for prefix, t := range c.types {
+		if len(prefix) <= matched || !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if rest := path[len(prefix):]; rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasSuffix(prefix, "/") {
+			continue
+		}
+		resourceType, matched = t, len(prefix)
+	}
+	return resourceType
+}
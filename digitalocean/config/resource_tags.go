@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// taggableCreatePaths are the endpoints creating resources that accept a
+// "tags" list in their request body.
+var taggableCreatePaths = []string{
+	"/v2/droplets",
+	"/v2/volumes",
+	"/v2/images",
+	"/v2/load_balancers",
+	"/v2/kubernetes/clusters",
+	"/v2/databases",
+}
+
+// resourceTagsTransport merges tags into the body of the POST requests
+// creating taggable resources. It sits above the retrying transport so that
+// the body is rewritten once for all attempts.
+type resourceTagsTransport struct {
+	base http.RoundTripper
+	tags []string
+}
+
+func (t *resourceTagsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || req.Body == nil || req.Body == http.NoBody || !isTaggableCreatePath(req.URL.Path) {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if tagged, ok := mergeResourceTags(body, t.tags); ok {
+		body = tagged
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+
+	return t.base.RoundTrip(req)
+}
+
+// isTaggableCreatePath reports whether path, which may be below a base path,
+// creates a taggable resource.
+func isTaggableCreatePath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, create := range taggableCreatePaths {
+		if strings.HasSuffix(path, create) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeResourceTags appends the tags missing from the "tags" list of the JSON
+// object body. It returns false, leaving the body as is, when body is not a
+// JSON object or its tags are not a list of strings.
+func mergeResourceTags(body []byte, tags []string) ([]byte, bool) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil || object == nil {
+		return nil, false
+	}
+
+	var existing []string
+	if raw, ok := object["tags"]; ok {
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return nil, false
+		}
+	}
+
+	seen := make(map[string]bool, len(existing))
+	for _, tag := range existing {
+		seen[tag] = true
+	}
+	for _, tag := range tags {
+		if !seen[tag] {
+			existing = append(existing, tag)
+			seen[tag] = true
+		}
+	}
+
+	raw, err := json.Marshal(existing)
+	if err != nil {
+		return nil, false
+	}
+	object["tags"] = raw
+
+	tagged, err := json.Marshal(object)
+	if err != nil {
+		return nil, false
+	}
+	return tagged, true
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClient_DefaultResourceTags(t *testing.T) {
+	cases := []struct {
+		Name         string
+		Method       string
+		Path         string
+		Body         map[string]interface{}
+		ExpectedTags []string
+		Untouched    bool
+	}{
+		{
+			Name:         "droplet create",
+			Method:       http.MethodPost,
+			Path:         "/v2/droplets",
+			Body:         map[string]interface{}{"name": "web", "size": "s-1vcpu-1gb", "tags": []string{"web", "terraform"}},
+			ExpectedTags: []string{"web", "terraform", "managed-by:terraform"},
+		},
+		{
+			Name:         "volume create without tags",
+			Method:       http.MethodPost,
+			Path:         "/v2/volumes",
+			Body:         map[string]interface{}{"name": "data", "size_gigabytes": 10},
+			ExpectedTags: []string{"terraform", "managed-by:terraform"},
+		},
+		{
+			Name:      "droplet action",
+			Method:    http.MethodPost,
+			Path:      "/v2/droplets/1/actions",
+			Body:      map[string]interface{}{"type": "reboot"},
+			Untouched: true,
+		},
+		{
+			Name:      "firewall create",
+			Method:    http.MethodPost,
+			Path:      "/v2/firewalls",
+			Body:      map[string]interface{}{"name": "web", "tags": []string{"web"}},
+			Untouched: true,
+		},
+		{
+			Name:      "droplet update",
+			Method:    http.MethodPut,
+			Path:      "/v2/droplets",
+			Body:      map[string]interface{}{"name": "web"},
+			Untouched: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var bodies [][]byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, body)
+				if len(bodies) == 1 {
+					// The retry must carry the tags too.
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.DefaultResourceTags = []string{"terraform", "managed-by:terraform"}
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			godoClient := client.GodoClient()
+			req, err := godoClient.NewRequest(context.Background(), tc.Method, tc.Path, tc.Body)
+			if err != nil {
+				t.Fatalf("unable to build request: %s", err)
+			}
+			original, _ := json.Marshal(tc.Body)
+
+			if _, err := godoClient.Do(context.Background(), req, nil); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(bodies) != 2 {
+				t.Fatalf("Expected 2 attempts, got %d", len(bodies))
+			}
+
+			for i, body := range bodies {
+				if tc.Untouched {
+					if string(body) != string(original)+"\n" {
+						t.Fatalf("Expected attempt %d to send %s untouched, got %s", i, original, body)
+					}
+					continue
+				}
+
+				var sent map[string]interface{}
+				if err := json.Unmarshal(body, &sent); err != nil {
+					t.Fatalf("Expected attempt %d to send JSON, got %s", i, body)
+				}
+				var tags []string
+				for _, tag := range sent["tags"].([]interface{}) {
+					tags = append(tags, tag.(string))
+				}
+				if !reflect.DeepEqual(tags, tc.ExpectedTags) {
+					t.Fatalf("Expected attempt %d to send tags %v, got %v", i, tc.ExpectedTags, tags)
+				}
+				if sent["name"] != tc.Body["name"] {
+					t.Fatalf("Expected attempt %d to keep the name %v, got %v", i, tc.Body["name"], sent["name"])
+				}
+			}
+		})
+	}
+}
+
+func TestMergeResourceTags_Malformed(t *testing.T) {
+	for _, body := range []string{`[]`, `null`, `{"tags":"web"}`, `{"name":`} {
+		if _, ok := mergeResourceTags([]byte(body), []string{"terraform"}); ok {
+			t.Fatalf("Expected %s to be left as is", body)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestResourceClassifier(t *testing.T) {
+	classifier := newResourceClassifier(map[string]string{
+		"/v2/apps":              "app_platform",
+		"/v2/databases/options": "database_options",
+	})
+
+	cases := []struct {
+		Path     string
+		Expected string
+	}{
+		{Path: "/v2/droplets", Expected: "droplets"},
+		{Path: "/v2/droplets/123/actions", Expected: "droplets"},
+		{Path: "/v2/databases/abc/users", Expected: "databases"},
+		{Path: "/v2/databases/options", Expected: "database_options"},
+		{Path: "/v2/kubernetes/clusters/abc/node_pools", Expected: "kubernetes"},
+		{Path: "/v2/floating_ips/1.2.3.4", Expected: "reserved_ips"},
+		{Path: "/v2/apps/abc/deployments", Expected: "app_platform"},
+		{Path: "/v2/volumesnapshots", Expected: "other"},
+		{Path: "/v2/unknown", Expected: "other"},
+		{Path: "", Expected: "other"},
+	}
+
+	for _, tc := range cases {
+		if resourceType := classifier.classify(tc.Path); resourceType != tc.Expected {
+			t.Errorf("Expected %q to be classified as %q, got %q", tc.Path, tc.Expected, resourceType)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// retryPolicy decides whether a request should be retried. It extends
+// retryablehttp.DefaultRetryPolicy with the rules configured on Config.
+type retryPolicy struct {
+	bodySubstrings      []string
+	quotaExceededMarker string
+	retryHeader         string
+	retryDNSErrors      bool
+	retryConflicts      bool
+	safeMethodsOnly     bool
+	ignoreReset         bool
+	errorBodyOn200      bool
+	retryMax            int
+	maxOverride         int
+	statusLimits        map[int]int
+}
+
+func newRetryPolicy(c *Config) *retryPolicy {
+	return &retryPolicy{
+		bodySubstrings:      c.RetryOnBodySubstrings,
+		quotaExceededMarker: c.QuotaExceededMarker,
+		retryHeader:         c.RetryOnHeader,
+		retryDNSErrors:      c.RetryDNSErrors == nil || *c.RetryDNSErrors,
+		retryConflicts:      c.RetryOn409,
+		safeMethodsOnly:     c.RetrySafeMethodsOnly,
+		ignoreReset:         c.DisableResetAwareBackoff,
+		errorBodyOn200:      c.TreatErrorBodyOn200AsFailure,
+		retryMax:            c.retryMax(),
+		maxOverride:         c.maxRetryOverride(),
+		statusLimits:        c.RetryLimitsByStatus,
+	}
+}
+
+// RetryOverride overrides the retry settings of Config for a single request.
+type RetryOverride struct {
+	// Max is the maximum number of retries of the request, in place of
+	// HTTPRetryMax. It may be higher than HTTPRetryMax, up to
+	// MaxRetryOverride.
+	Max int
+}
+
+type retryOverrideKey struct{}
+
+// WithRetryOverride returns a context that applies override to the requests
+// made with it.
+func WithRetryOverride(ctx context.Context, override RetryOverride) context.Context {
+	return context.WithValue(ctx, retryOverrideKey{}, override)
+}
+
+// retryOverrideFrom returns the override attached to ctx, if any.
+func retryOverrideFrom(ctx context.Context) (RetryOverride, bool) {
+	override, ok := ctx.Value(retryOverrideKey{}).(RetryOverride)
+	return override, ok
+}
+
+// CheckRetry satisfies retryablehttp.CheckRetry.
+func (p *retryPolicy) CheckRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if state := requestStateFrom(ctx); state != nil {
+		state.recordAttempt(resp, err)
+	}
+
+	shouldRetry, checkErr := p.checkRetry(ctx, resp, err)
+	if checkErr != nil {
+		return shouldRetry, checkErr
+	}
+
+	// A 200 carrying an error body is retried, and must still fail when it
+	// is not.
+	errorBody := p.checkErrorBodyOn200(resp)
+	if !shouldRetry && errorBody == nil {
+		return false, nil
+	}
+
+	if p.safeMethodsOnly && !isRetrySafeMethod(requestMethod(ctx, resp)) && !isDialError(err) {
+		return false, errorBody
+	}
+
+	if deadline, ok := softDeadlineFrom(ctx); ok && !time.Now().Before(deadline) {
+		return false, fmt.Errorf("%w: not retrying after the deadline of %s", ErrSoftDeadlineExceeded, deadline.Format(time.RFC3339))
+	}
+
+	limit, hasOverride := p.limit(ctx)
+
+	// The retryable client may allow more retries than this request, see
+	// retryLimitTransport, so its limit is enforced here. Without an
+	// override, the status limits replace HTTPRetryMax.
+	if state := requestStateFrom(ctx); state != nil && (hasOverride || len(p.statusLimits) == 0) {
+		state.mu.Lock()
+		retries := state.attempts - 1
+		state.mu.Unlock()
+
+		if retries >= limit {
+			return false, retriesExhausted(err, errorBody)
+		}
+	}
+
+	if !p.withinStatusLimit(ctx, resp, limit) {
+		return false, retriesExhausted(err, errorBody)
+	}
+
+	return true, nil
+}
+
+// limit returns the maximum number of retries of the request of ctx, and
+// whether it is set by a RetryOverride.
+func (p *retryPolicy) limit(ctx context.Context) (int, bool) {
+	override, ok := retryOverrideFrom(ctx)
+	if !ok {
+		return p.retryMax, false
+	}
+	if override.Max > p.maxOverride {
+		return p.maxOverride, true
+	}
+	return override.Max, true
+}
+
+// maxRetries returns the most retries the request of ctx may need: its
+// limit, or without an override the highest of it and RetryLimitsByStatus.
+func (p *retryPolicy) maxRetries(ctx context.Context) int {
+	limit, hasOverride := p.limit(ctx)
+	if hasOverride {
+		return limit
+	}
+	for _, statusLimit := range p.statusLimits {
+		if statusLimit > limit {
+			limit = statusLimit
+		}
+	}
+	return limit
+}
+
+// retryLimitTransport sends the requests that may be retried more than
+// HTTPRetryMax times, by a RetryOverride or RetryLimitsByStatus, to a copy of
+// the retryable client with a higher RetryMax. Other requests, and those
+// without a requestState to count their retries, go to base, whose RetryMax
+// is HTTPRetryMax.
+type retryLimitTransport struct {
+	base   http.RoundTripper
+	client *retryablehttp.Client
+	policy *retryPolicy
+
+	// extended holds the retrying transports by RetryMax.
+	extended sync.Map
+}
+
+func (t *retryLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	max := t.policy.maxRetries(req.Context())
+	if max <= t.client.RetryMax || requestStateFrom(req.Context()) == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	transport, ok := t.extended.Load(max)
+	if !ok {
+		transport, _ = t.extended.LoadOrStore(max, withRetryMax(t.client, max).StandardClient().Transport)
+	}
+	return transport.(http.RoundTripper).RoundTrip(req)
+}
+
+// withRetryMax returns a copy of c that retries requests up to max times.
+func withRetryMax(c *retryablehttp.Client, max int) *retryablehttp.Client {
+	return &retryablehttp.Client{
+		HTTPClient:      c.HTTPClient,
+		Logger:          c.Logger,
+		RetryWaitMin:    c.RetryWaitMin,
+		RetryWaitMax:    c.RetryWaitMax,
+		RetryMax:        max,
+		RequestLogHook:  c.RequestLogHook,
+		ResponseLogHook: c.ResponseLogHook,
+		CheckRetry:      c.CheckRetry,
+		Backoff:         c.Backoff,
+		ErrorHandler:    c.ErrorHandler,
+	}
+}
+
+func (p *retryPolicy) checkRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	var hostErr *hostNotAllowedError
+	if errors.As(err, &hostErr) || errors.Is(err, ErrTransferLimitExceeded) || errors.Is(err, ErrGlobalDeadlineExceeded) {
+		return false, nil
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			// A host that does not exist will not appear by retrying.
+			return false, nil
+		case dnsErr.IsTemporary || dnsErr.IsTimeout:
+			return p.retryDNSErrors, nil
+		}
+	}
+
+	if resp != nil && isMaintenanceResponse(resp) {
+		if state := requestStateFrom(ctx); state != nil {
+			if eta, ok := maintenanceETA(resp, time.Now()); ok {
+				state.mu.Lock()
+				state.maintenanceUntil = eta
+				state.mu.Unlock()
+			}
+		}
+	}
+
+	if err := p.checkQuotaExceeded(resp); err != nil {
+		return false, err
+	}
+
+	if !p.ignoreReset {
+		if err := checkResetDeadline(ctx, resp); err != nil {
+			return false, err
+		}
+	}
+
+	shouldRetry, checkErr := retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+	if shouldRetry || checkErr != nil || resp == nil {
+		return shouldRetry, checkErr
+	}
+
+	if p.retryConflicts && resp.StatusCode == http.StatusConflict {
+		return true, nil
+	}
+
+	if p.retryHeader != "" && isTruthyHeader(resp.Header.Get(p.retryHeader)) {
+		return true, nil
+	}
+
+	if len(p.bodySubstrings) > 0 {
+		body, err := inspectBody(resp)
+		if err != nil {
+			// The body could not be read, so retrying is the safest option.
+			return true, nil
+		}
+		for _, substr := range p.bodySubstrings {
+			if substr != "" && bytes.Contains(body, []byte(substr)) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// errRetriesExhausted is returned by the retry policy when a request has used
+// up its retries, so that retryErrorHandler reports it as retryablehttp does.
+var errRetriesExhausted = errors.New("retries exhausted")
+
+// retriesExhausted returns the error that ends a request whose retries are
+// used up: the error of the 200 body or of the last attempt, if any.
+func retriesExhausted(err, errorBody error) error {
+	if errorBody != nil {
+		return errorBody
+	}
+	if err != nil {
+		return err
+	}
+	return errRetriesExhausted
+}
+
+// withinStatusLimit counts a retry of resp against the limit of its status
+// code in RetryLimitsByStatus and reports whether the limit allows it.
+// Responses with other status codes and errors share limit.
+func (p *retryPolicy) withinStatusLimit(ctx context.Context, resp *http.Response, limit int) bool {
+	if len(p.statusLimits) == 0 {
+		return true
+	}
+	state := requestStateFrom(ctx)
+	if state == nil {
+		return true
+	}
+
+	status := 0
+	if resp != nil {
+		if statusLimit, ok := p.statusLimits[resp.StatusCode]; ok {
+			status, limit = resp.StatusCode, statusLimit
+		}
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.retriesByStatus == nil {
+		state.retriesByStatus = make(map[int]int)
+	}
+	if state.retriesByStatus[status] >= limit {
+		return false
+	}
+	state.retriesByStatus[status]++
+	return true
+}
+
+// isRetrySafeMethod reports whether requests of the given method may be
+// retried with RetrySafeMethodsOnly: a repeated PUT or DELETE has the same
+// effect as a single one.
+func isRetrySafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestMethod returns the method of the request being retried.
+func requestMethod(ctx context.Context, resp *http.Response) string {
+	if state := requestStateFrom(ctx); state != nil {
+		return state.method
+	}
+	if resp != nil && resp.Request != nil {
+		return resp.Request.Method
+	}
+	return ""
+}
+
+// isDialError reports whether err happened while connecting, before the
+// request could reach the server.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isTruthyHeader reports whether a header value requests a retry: it must be
+// set and not be a false boolean such as "false" or "0".
+func isTruthyHeader(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	return true
+}
+
+// checkQuotaExceeded returns a QuotaExceededError when resp is a 429 whose
+// body contains the quota exceeded marker.
+func (p *retryPolicy) checkQuotaExceeded(resp *http.Response) error {
+	if p.quotaExceededMarker == "" || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	body, err := inspectBody(resp)
+	if err != nil || !bytes.Contains(body, []byte(p.quotaExceededMarker)) {
+		return nil
+	}
+
+	return &QuotaExceededError{APIError: parseAPIError(resp)}
+}
+
+// checkErrorBodyOn200 returns the DigitalOcean error carried by the body of
+// resp when it is a 200 and TreatErrorBodyOn200AsFailure is set. Only JSON
+// bodies with both an error id and message count, so that resources with
+// such fields are not mistaken for errors.
+func (p *retryPolicy) checkErrorBodyOn200(resp *http.Response) error {
+	if !p.errorBodyOn200 || resp == nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return nil
+	}
+
+	apiErr := parseAPIError(resp)
+	if apiErr == nil || apiErr.ID == "" || apiErr.Message == "" {
+		return nil
+	}
+	return apiErr
+}
+
+// checkResetDeadline returns a RateLimitDeadlineError when resp is a 429
+// whose rate limit resets after the deadline of ctx.
+func checkResetDeadline(ctx context.Context, resp *http.Response) error {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	sleep, ok := rateLimitResetSleep(resp)
+	if !ok {
+		return nil
+	}
+
+	if remaining := time.Until(deadline); sleep > remaining {
+		return &RateLimitDeadlineError{Reset: sleep, Remaining: remaining}
+	}
+	return nil
+}
+
+// maxPeekedBodySize bounds how much of a response body is read to inspect
+// it, so that large responses, e.g. list pages, keep streaming.
+const maxPeekedBodySize = 64 << 10
+
+// peekBody reads up to maxPeekedBodySize bytes of the response body and puts
+// them back in front of the unread remainder, so that the body remains
+// readable downstream.
+func peekBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil, nil
+	}
+
+	head, err := io.ReadAll(io.LimitReader(resp.Body, maxPeekedBodySize))
+	if err != nil {
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(head))
+		return head, err
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	return head, nil
+}
+
+// bufferBody reads the full response body and replaces it with an equivalent
+// reader so that it remains readable downstream.
+func bufferBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, err
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// defaultMaxRetryBufferBytes is the body size buffered for retries when
+// MaxRetryBufferBytes is not set.
+const defaultMaxRetryBufferBytes = 4 << 20
+
+// maxRetryBufferBytes returns the largest request or response body buffered
+// so that the request can be retried.
+func (c *Config) maxRetryBufferBytes() int64 {
+	if c.MaxRetryBufferBytes <= 0 {
+		return defaultMaxRetryBufferBytes
+	}
+	return c.MaxRetryBufferBytes
+}
+
+// retryBufferTransport sends the requests whose body is larger than max
+// straight to direct, bypassing the retrying transport, which would buffer
+// the whole body in memory to replay it. Such requests are not retried.
+type retryBufferTransport struct {
+	retrying http.RoundTripper
+	direct   http.RoundTripper
+	max      int64
+}
+
+func (t *retryBufferTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || (req.ContentLength > 0 && req.ContentLength <= t.max) {
+		return t.retrying.RoundTrip(req)
+	}
+
+	if req.ContentLength <= 0 {
+		// A body with a zero length has an unknown length: peek at it to
+		// decide.
+		head, err := io.ReadAll(io.LimitReader(req.Body, t.max+1))
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		if int64(len(head)) <= t.max {
+			req.Body.Close()
+			req.Body = io.NopCloser(bytes.NewReader(head))
+			req.ContentLength = int64(len(head))
+			return t.retrying.RoundTrip(req)
+		}
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), req.Body), req.Body}
+	}
+
+	return t.direct.RoundTrip(req)
+}
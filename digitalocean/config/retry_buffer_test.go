@@ -0,0 +1,109 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_MaxRetryBufferBytes(t *testing.T) {
+	cases := []struct {
+		Name             string
+		BodySize         int
+		ExpectedAttempts int32
+	}{
+		{Name: "within limit", BodySize: 512, ExpectedAttempts: 4},
+		{Name: "beyond limit", BodySize: 2048, ExpectedAttempts: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if len(body) < tc.BodySize {
+					t.Errorf("Expected a body of at least %d bytes, got %d", tc.BodySize, len(body))
+				}
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.MaxRetryBufferBytes = 1024
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			godoClient := client.GodoClient()
+			req, err := godoClient.NewRequest(context.Background(), http.MethodPost, "/v2/images",
+				map[string]string{"description": strings.Repeat("a", tc.BodySize)})
+			if err != nil {
+				t.Fatalf("unable to build request: %s", err)
+			}
+			if _, err := godoClient.Do(context.Background(), req, nil); err == nil {
+				t.Fatalf("Expected an error")
+			}
+
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+// recordingRoundTripper records the bodies of the requests sent through it.
+type recordingRoundTripper struct {
+	bodies []string
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.bodies = append(r.bodies, string(body))
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestRetryBufferTransport_UnknownLength(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Body          string
+		ExpectRetried bool
+	}{
+		{Name: "small", Body: strings.Repeat("a", 16), ExpectRetried: true},
+		{Name: "at limit", Body: strings.Repeat("a", 32), ExpectRetried: true},
+		{Name: "large", Body: strings.Repeat("a", 33)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			retrying, direct := &recordingRoundTripper{}, &recordingRoundTripper{}
+			transport := &retryBufferTransport{retrying: retrying, direct: direct, max: 32}
+
+			// Hiding the reader's type leaves the length unknown.
+			body := struct{ io.Reader }{bytes.NewBufferString(tc.Body)}
+			req, _ := http.NewRequest(http.MethodPost, "https://api.digitalocean.com/v2/images", body)
+
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			used, unused := direct, retrying
+			if tc.ExpectRetried {
+				used, unused = retrying, direct
+			}
+			if len(used.bodies) != 1 || used.bodies[0] != tc.Body || len(unused.bodies) != 0 {
+				t.Fatalf("Expected the full body to be sent once through the right transport, got %q and %q", used.bodies, unused.bodies)
+			}
+		})
+	}
+}
@@ -0,0 +1,779 @@
+package config
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+func TestRetryOnBodySubstrings(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			io.WriteString(w, `{"id":"unprocessable_entity","message":"resource is locked, try again"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RetryOnBodySubstrings = []string{"resource is locked"}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryOnBodySubstrings_NoMatch(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, `{"id":"unprocessable_entity","message":"name is invalid"}`)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RetryOnBodySubstrings = []string{"resource is locked"}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets")
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	if attempts != 1 {
+		t.Fatalf("Expected 1 attempt, got %d", attempts)
+	}
+
+	// The body must still be readable downstream once inspected.
+	errResp, ok := err.(*godo.ErrorResponse)
+	if !ok {
+		t.Fatalf("Expected *godo.ErrorResponse, got %T", err)
+	}
+	if errResp.Message != "name is invalid" {
+		t.Fatalf("Expected message to survive body inspection, got %q", errResp.Message)
+	}
+}
+
+func TestRetryOnBodySubstrings_GzipEncoded(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			// The body is long enough to be compressed rather than stored.
+			gz := gzip.NewWriter(w)
+			io.WriteString(gz, `{"id":"unprocessable_entity","message":"resource is locked, try again",`+
+				`"details":"`+strings.Repeat("resource is locked ", 16)+`"}`)
+			gz.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RetryOnBodySubstrings = []string{"resource is locked"}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Asking for gzip explicitly stops net/http from decompressing the body.
+	req, err := client.GodoClient().NewRequest(context.Background(), http.MethodPost, "/v2/droplets", nil)
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	if _, err := client.GodoClient().Do(context.Background(), req, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestClient_RateLimitResetExceedsDeadline(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set(headerRateReset, resetIn(time.Minute))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	godoClient := client.GodoClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, err := godoClient.NewRequest(ctx, http.MethodGet, "/v2/account", nil)
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+
+	start := time.Now()
+	_, err = godoClient.Do(ctx, req, nil)
+
+	var deadlineErr *RateLimitDeadlineError
+	if !errors.As(err, &deadlineErr) {
+		t.Fatalf("Expected a RateLimitDeadlineError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "exceeds remaining context deadline") {
+		t.Fatalf("Expected a descriptive error, got %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Expected to fail without waiting for the deadline, took %s", elapsed)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("Expected a single attempt, got %d", n)
+	}
+}
+
+func TestClient_RetryOverride(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Override         *RetryOverride
+		MaxOverride      int
+		ExpectedAttempts int32
+	}{
+		{
+			Name:             "global setting",
+			ExpectedAttempts: 4,
+		},
+		{
+			Name:             "retries disabled",
+			Override:         &RetryOverride{Max: 0},
+			ExpectedAttempts: 1,
+		},
+		{
+			Name:             "fewer retries",
+			Override:         &RetryOverride{Max: 1},
+			ExpectedAttempts: 2,
+		},
+		{
+			Name:             "more retries than HTTPRetryMax",
+			Override:         &RetryOverride{Max: 6},
+			ExpectedAttempts: 7,
+		},
+		{
+			Name:             "capped by MaxRetryOverride",
+			Override:         &RetryOverride{Max: 20},
+			MaxOverride:      5,
+			ExpectedAttempts: 6,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.MaxRetryOverride = tc.MaxOverride
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			godoClient := client.GodoClient()
+
+			ctx := context.Background()
+			if tc.Override != nil {
+				ctx = WithRetryOverride(ctx, *tc.Override)
+			}
+
+			req, err := godoClient.NewRequest(ctx, http.MethodGet, "/v2/account", nil)
+			if err != nil {
+				t.Fatalf("unable to build request: %s", err)
+			}
+			if _, err := godoClient.Do(ctx, req, nil); err == nil {
+				t.Fatalf("Expected an error")
+			}
+
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestClient_QuotaExceededMarker(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Body             string
+		ExpectQuota      bool
+		ExpectedAttempts int32
+	}{
+		{
+			Name:             "throttled",
+			Body:             `{"id":"too_many_requests","message":"API Rate limit exceeded."}`,
+			ExpectedAttempts: 4,
+		},
+		{
+			Name:             "quota exceeded",
+			Body:             `{"id":"too_many_requests","message":"Monthly quota exceeded."}`,
+			ExpectQuota:      true,
+			ExpectedAttempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(http.StatusTooManyRequests)
+				io.WriteString(w, tc.Body)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.QuotaExceededMarker = "Monthly quota exceeded"
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+
+			var quotaErr *QuotaExceededError
+			if errors.As(err, &quotaErr) != tc.ExpectQuota {
+				t.Fatalf("Expected a QuotaExceededError to be %t, got %s", tc.ExpectQuota, err)
+			}
+			if tc.ExpectQuota && (quotaErr.APIError == nil || quotaErr.APIError.Message != "Monthly quota exceeded.") {
+				t.Fatalf("Expected the API error to be kept, got %s", err)
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestClient_RetryOnHeader(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Value            string
+		ExpectedAttempts int32
+	}{
+		{Name: "truthy", Value: "true", ExpectedAttempts: 2},
+		{Name: "present", Value: "gateway-busy", ExpectedAttempts: 2},
+		{Name: "false", Value: "false", ExpectedAttempts: 1},
+		{Name: "absent", ExpectedAttempts: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 && tc.Value != "" {
+					w.Header().Set("X-Retry", tc.Value)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.RetryOnHeader = "X-Retry"
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_DNSErrors(t *testing.T) {
+	disabled := false
+	temporary := &url.Error{Op: "Get", URL: "https://api.digitalocean.com/v2/account",
+		Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "server misbehaving", Name: "api.digitalocean.com", IsTemporary: true}}}
+	notFound := &url.Error{Op: "Get", URL: "https://api.digitalocean.com/v2/account",
+		Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "api.digitalocean.com", IsNotFound: true}}}
+
+	cases := []struct {
+		Name           string
+		RetryDNSErrors *bool
+		Err            error
+		ExpectRetry    bool
+	}{
+		{Name: "temporary", Err: temporary, ExpectRetry: true},
+		{Name: "temporary with retries disabled", RetryDNSErrors: &disabled, Err: temporary},
+		{Name: "not found", Err: notFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			c.RetryDNSErrors = tc.RetryDNSErrors
+
+			retry, err := newRetryPolicy(c).CheckRetry(context.Background(), nil, tc.Err)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if retry != tc.ExpectRetry {
+				t.Fatalf("Expected retry to be %t, got %t", tc.ExpectRetry, retry)
+			}
+		})
+	}
+}
+
+func TestClient_HTTPRetryMax(t *testing.T) {
+	cases := []struct {
+		Name             string
+		RetryMax         int
+		ExpectedAttempts int32
+	}{
+		{Name: "zero disables retries", RetryMax: 0, ExpectedAttempts: 1},
+		{Name: "negative uses the default", RetryMax: -1, ExpectedAttempts: defaultHTTPRetryMax + 1},
+		{Name: "positive", RetryMax: 2, ExpectedAttempts: 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.HTTPRetryMax = tc.RetryMax
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestClient_RetryLimitsByStatus(t *testing.T) {
+	limits := map[int]int{http.StatusTooManyRequests: 6, http.StatusInternalServerError: 2}
+
+	cases := []struct {
+		Name             string
+		Status           int
+		ExpectedAttempts int32
+	}{
+		{Name: "limited below HTTPRetryMax", Status: http.StatusInternalServerError, ExpectedAttempts: 3},
+		{Name: "limited above HTTPRetryMax", Status: http.StatusTooManyRequests, ExpectedAttempts: 7},
+		{Name: "unlisted", Status: http.StatusBadGateway, ExpectedAttempts: 4},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tc.Status)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.RetryLimitsByStatus = limits
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestClient_RetryLimitsByStatusMixed(t *testing.T) {
+	// A 500 stops the request once its limit is reached, even after the
+	// 429s retried beyond HTTPRetryMax.
+	statuses := []int{429, 429, 429, 429, 500, 500, 500, 200}
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(statuses[n-1])
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RetryLimitsByStatus = map[int]int{http.StatusTooManyRequests: 10, http.StatusInternalServerError: 2}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if n := atomic.LoadInt32(&attempts); n != 7 {
+		t.Fatalf("Expected 7 attempts, got %d", n)
+	}
+}
+
+func TestClient_RetryOn409(t *testing.T) {
+	cases := []struct {
+		Name             string
+		RetryOn409       bool
+		ExpectErr        bool
+		ExpectedAttempts int32
+	}{
+		{Name: "enabled", RetryOn409: true, ExpectedAttempts: 2},
+		{Name: "disabled", ExpectErr: true, ExpectedAttempts: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					w.WriteHeader(http.StatusConflict)
+					io.WriteString(w, `{"id":"conflict","message":"droplet is being deleted"}`)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.RetryOn409 = tc.RetryOn409
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			_, err = doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets")
+			if (err != nil) != tc.ExpectErr {
+				t.Fatalf("Expected error to be %t, got %v", tc.ExpectErr, err)
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestClient_TreatErrorBodyOn200AsFailure(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Enabled          bool
+		Failures         int32
+		ExpectErr        string
+		ExpectedAttempts int32
+		ExpectedEmail    string
+	}{
+		{Name: "retried", Enabled: true, Failures: 1, ExpectedAttempts: 2, ExpectedEmail: "user@example.com"},
+		{Name: "retries exhausted", Enabled: true, Failures: 10, ExpectErr: "Server is overloaded", ExpectedAttempts: 4},
+		{Name: "disabled", Failures: 1, ExpectedAttempts: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if atomic.AddInt32(&attempts, 1) <= tc.Failures {
+					io.WriteString(w, `{"id":"service_unavailable","message":"Server is overloaded."}`)
+					return
+				}
+				io.WriteString(w, `{"account":{"email":"user@example.com"}}`)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.TreatErrorBodyOn200AsFailure = tc.Enabled
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			account, _, err := client.GodoClient().Account.Get(context.Background())
+			if tc.ExpectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.ExpectErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tc.ExpectErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if tc.ExpectedEmail != "" && account.Email != tc.ExpectedEmail {
+				// The body inspected for an error is still decoded by godo.
+				t.Fatalf("Expected email %q, got %q", tc.ExpectedEmail, account.Email)
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestClient_RetrySafeMethodsOnly(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Method           string
+		SafeMethodsOnly  bool
+		ExpectedAttempts int32
+	}{
+		{Name: "GET", Method: http.MethodGet, SafeMethodsOnly: true, ExpectedAttempts: 2},
+		{Name: "HEAD", Method: http.MethodHead, SafeMethodsOnly: true, ExpectedAttempts: 2},
+		{Name: "OPTIONS", Method: http.MethodOptions, SafeMethodsOnly: true, ExpectedAttempts: 2},
+		{Name: "PUT", Method: http.MethodPut, SafeMethodsOnly: true, ExpectedAttempts: 2},
+		{Name: "DELETE", Method: http.MethodDelete, SafeMethodsOnly: true, ExpectedAttempts: 2},
+		{Name: "POST", Method: http.MethodPost, SafeMethodsOnly: true, ExpectedAttempts: 1},
+		{Name: "PATCH", Method: http.MethodPatch, SafeMethodsOnly: true, ExpectedAttempts: 1},
+		{Name: "POST by default", Method: http.MethodPost, ExpectedAttempts: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.RetrySafeMethodsOnly = tc.SafeMethodsOnly
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			_, err = doRequest(t, client.GodoClient(), tc.Method, "/v2/droplets/1")
+			if expectErr := tc.ExpectedAttempts == 1; (err != nil) != expectErr {
+				t.Fatalf("Expected error to be %t, got %v", expectErr, err)
+			}
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+// failingTransport fails every request with err.
+type failingTransport struct {
+	err      error
+	attempts int32
+}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.attempts, 1)
+	return nil, t.err
+}
+
+func TestClient_RetrySafeMethodsOnlyConnectionErrors(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Err              error
+		ExpectedAttempts int32
+	}{
+		{
+			Name:             "dial error",
+			Err:              &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			ExpectedAttempts: 4,
+		},
+		{
+			Name:             "read error",
+			Err:              &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")},
+			ExpectedAttempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			transport := &failingTransport{err: tc.Err}
+			conf := testConfig("https://api.digitalocean.com")
+			conf.RetrySafeMethodsOnly = true
+			conf.BaseTransport = transport
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, err := doRequest(t, client.GodoClient(), http.MethodPost, "/v2/droplets"); err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if n := atomic.LoadInt32(&transport.attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+func TestPeekBody_Bounded(t *testing.T) {
+	body := strings.Repeat("x", 4*maxPeekedBodySize)
+	source := &countingReader{r: strings.NewReader(body)}
+	resp := testResponse(http.StatusOK, nil)
+	resp.Body = io.NopCloser(source)
+
+	head, err := peekBody(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(head) != maxPeekedBodySize || source.read > 2*maxPeekedBodySize {
+		t.Fatalf("Expected a peek of %d bytes, got %d after reading %d", maxPeekedBodySize, len(head), source.read)
+	}
+
+	rest, _ := io.ReadAll(resp.Body)
+	if string(rest) != body {
+		t.Fatalf("Expected the full body to remain readable, got %d bytes", len(rest))
+	}
+}
+
+func TestRetryPolicy_ErrorBodyOn200RequiresJSON(t *testing.T) {
+	cases := []struct {
+		Name        string
+		ContentType string
+		ExpectRetry bool
+	}{
+		{Name: "json", ContentType: "application/json; charset=utf-8", ExpectRetry: true},
+		{Name: "text", ContentType: "text/plain"},
+		{Name: "missing"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			c.TreatErrorBodyOn200AsFailure = true
+
+			resp := testResponse(http.StatusOK, map[string]string{"Content-Type": tc.ContentType})
+			resp.Body = io.NopCloser(strings.NewReader(`{"id":"service_unavailable","message":"Server is overloaded."}`))
+
+			retry, err := newRetryPolicy(c).CheckRetry(context.Background(), resp, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if retry != tc.ExpectRetry {
+				t.Fatalf("Expected retry to be %t, got %t", tc.ExpectRetry, retry)
+			}
+		})
+	}
+}
+
+func TestRetryLimitTransport_RequiresState(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	policy := newRetryPolicy(conf)
+	client := retryablehttp.NewClient()
+	client.RetryMax = conf.retryMax()
+	client.RetryWaitMin, client.RetryWaitMax = time.Millisecond, time.Millisecond
+	client.CheckRetry = policy.CheckRetry
+	client.ErrorHandler = retryablehttp.PassthroughErrorHandler
+	client.Logger = nil
+
+	transport := &retryLimitTransport{base: client.StandardClient().Transport, client: client, policy: policy}
+
+	cases := []struct {
+		Name             string
+		State            bool
+		ExpectedAttempts int32
+	}{
+		// Without a state the retries of the request cannot be counted, so
+		// HTTPRetryMax applies.
+		{Name: "without state", ExpectedAttempts: 4},
+		{Name: "with state", State: true, ExpectedAttempts: 7},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+
+			ctx := WithRetryOverride(context.Background(), RetryOverride{Max: 6})
+			if tc.State {
+				ctx = context.WithValue(ctx, requestStateKey{}, &requestState{method: http.MethodGet})
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if resp, err := transport.RoundTrip(req); err == nil {
+				resp.Body.Close()
+			}
+
+			if n := atomic.LoadInt32(&attempts); n != tc.ExpectedAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.ExpectedAttempts, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import "reflect"
+
+// redactedValue replaces secret values in SafeConfig output.
+const redactedValue = "[REDACTED]"
+
+// sensitiveFields lists the Config fields that must never be logged. Fields
+// tagged `sensitive:"true"` are masked as well, so that new credentials only
+// need to be tagged.
+var sensitiveFields = map[string]bool{
+	"Token":        true,
+	"AccessID":     true,
+	"SecretKey":    true,
+	"RefreshToken": true,
+}
+
+// isSensitiveField reports whether field holds a secret.
+func isSensitiveField(field reflect.StructField) bool {
+	return sensitiveFields[field.Name] || field.Tag.Get("sensitive") == "true"
+}
+
+// SafeConfig returns the effective configuration with secrets masked so it
+// can be logged or included in support bundles. Hooks and other values that
+// have no meaningful textual representation are omitted.
+func (c *Config) SafeConfig() map[string]any {
+	safe := make(map[string]any)
+
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value := v.Field(i)
+		switch value.Kind() {
+		case reflect.Func, reflect.Interface, reflect.Chan, reflect.Ptr, reflect.UnsafePointer:
+			continue
+		}
+
+		if isSensitiveField(field) {
+			if value.IsZero() {
+				safe[field.Name] = ""
+			} else {
+				safe[field.Name] = redactedValue
+			}
+			continue
+		}
+
+		safe[field.Name] = value.Interface()
+	}
+
+	return safe
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSafeConfig(t *testing.T) {
+	conf := testConfig("https://api.digitalocean.com")
+	conf.Token = "super-secret-token"
+	conf.AccessID = "access-id"
+	conf.SecretKey = "secret-key"
+	conf.RefreshToken = "refresh-token"
+	conf.RequestsPerSecond = 5
+
+	safe := conf.SafeConfig()
+
+	for _, field := range []string{"Token", "AccessID", "SecretKey", "RefreshToken"} {
+		if safe[field] != redactedValue {
+			t.Errorf("Expected %s to be redacted, got %v", field, safe[field])
+		}
+	}
+
+	expected := map[string]any{
+		"APIEndpoint":       conf.APIEndpoint,
+		"SpacesAPIEndpoint": conf.SpacesAPIEndpoint,
+		"RequestsPerSecond": conf.RequestsPerSecond,
+		"HTTPRetryMax":      conf.HTTPRetryMax,
+		"HTTPRetryWaitMin":  conf.HTTPRetryWaitMin,
+		"HTTPRetryWaitMax":  conf.HTTPRetryWaitMax,
+	}
+	for field, want := range expected {
+		if safe[field] != want {
+			t.Errorf("Expected %s to be %v, got %v", field, want, safe[field])
+		}
+	}
+
+	rendered := fmt.Sprintf("%v", safe)
+	for _, secret := range []string{conf.Token, conf.AccessID, conf.SecretKey, conf.RefreshToken} {
+		if strings.Contains(rendered, secret) {
+			t.Errorf("Expected %q not to appear in %s", secret, rendered)
+		}
+	}
+}
+
+func TestSafeConfig_EmptySecrets(t *testing.T) {
+	conf := &Config{}
+
+	safe := conf.SafeConfig()
+	if safe["Token"] != "" {
+		t.Fatalf("Expected empty Token to stay empty, got %v", safe["Token"])
+	}
+}
+
+func TestSafeConfig_SensitiveFieldsTagged(t *testing.T) {
+	typ := reflect.TypeOf(Config{})
+	for name := range sensitiveFields {
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			t.Fatalf("Expected sensitive field %s to exist on Config", name)
+		}
+		if field.Tag.Get("sensitive") != "true" {
+			t.Errorf("Expected sensitive field %s to be tagged", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultTokenInfoURL is the OAuth endpoint describing the current token.
+const defaultTokenInfoURL = "https://cloud.digitalocean.com/v1/oauth/token/info"
+
+// tokenInfo is the subset of the OAuth token info response used to check
+// the scopes granted to a token.
+type tokenInfo struct {
+	Scope  []string `json:"scope"`
+	Scopes []string `json:"scopes"`
+}
+
+// TokenScopes returns the scopes granted to the configured token.
+func (c *CombinedConfig) TokenScopes(ctx context.Context) ([]string, error) {
+	req, err := c.client.NewRequest(ctx, http.MethodGet, c.tokenInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info := new(tokenInfo)
+	if _, err := c.client.Do(ctx, req, info); err != nil {
+		return nil, fmt.Errorf("unable to retrieve token scopes: %s", err)
+	}
+
+	return append(info.Scope, info.Scopes...), nil
+}
+
+// RequireScopes returns an error listing any of the given scopes that have
+// not been granted to the configured token. Legacy full access tokens are
+// treated as granting every scope of their access level, e.g. a "read" token
+// satisfies "droplet:read".
+func (c *CombinedConfig) RequireScopes(ctx context.Context, scopes ...string) error {
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	granted, err := c.TokenScopes(ctx)
+	if err != nil {
+		return err
+	}
+
+	grantedSet := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		grantedSet[strings.ToLower(scope)] = true
+	}
+
+	var missing []string
+	for _, scope := range scopes {
+		if !scopeGranted(grantedSet, strings.ToLower(scope)) {
+			missing = append(missing, scope)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("token is missing required scopes: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func scopeGranted(granted map[string]bool, scope string) bool {
+	if granted[scope] || granted["write"] {
+		return true
+	}
+
+	if granted["read"] && (scope == "read" || strings.HasSuffix(scope, ":read")) {
+		return true
+	}
+
+	if resource, _, ok := strings.Cut(scope, ":"); ok && granted[resource+":*"] {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/oauth/token/info" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"scope":["droplet:read","droplet:create","vpc:*"]}`)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.TokenInfoURL = server.URL + "/v1/oauth/token/info"
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		Name    string
+		Scopes  []string
+		Missing []string
+	}{
+		{
+			Name: "none requested",
+		},
+		{
+			Name:   "all granted",
+			Scopes: []string{"droplet:read", "droplet:create"},
+		},
+		{
+			Name:   "wildcard resource",
+			Scopes: []string{"vpc:update"},
+		},
+		{
+			Name:    "missing scopes",
+			Scopes:  []string{"droplet:read", "droplet:delete", "database:create"},
+			Missing: []string{"droplet:delete", "database:create"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := client.RequireScopes(context.Background(), tc.Scopes...)
+			if len(tc.Missing) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			for _, scope := range tc.Missing {
+				if !strings.Contains(err.Error(), scope) {
+					t.Errorf("Expected error to mention %q, got %q", scope, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireScopes_FullAccessToken(t *testing.T) {
+	readOnly := map[string]bool{"read": true}
+	if !scopeGranted(readOnly, "droplet:read") {
+		t.Error("Expected a read token to satisfy droplet:read")
+	}
+	if scopeGranted(readOnly, "droplet:create") {
+		t.Error("Expected a read token not to satisfy droplet:create")
+	}
+
+	readWrite := map[string]bool{"read": true, "write": true}
+	if !scopeGranted(readWrite, "droplet:create") {
+		t.Error("Expected a write token to satisfy droplet:create")
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import "net/http"
+
+// serializeWritesTransport lets a single mutating request, including its
+// retries, run at a time. GET, HEAD and OPTIONS requests are not held back.
+type serializeWritesTransport struct {
+	base http.RoundTripper
+	slot chan struct{}
+}
+
+func newSerializeWritesTransport(base http.RoundTripper) *serializeWritesTransport {
+	return &serializeWritesTransport{base: base, slot: make(chan struct{}, 1)}
+}
+
+func (t *serializeWritesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.base.RoundTrip(req)
+	}
+
+	select {
+	case t.slot <- struct{}{}:
+	case <-req.Context().Done():
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, req.Context().Err()
+	}
+	defer func() { <-t.slot }()
+
+	return t.base.RoundTrip(req)
+}
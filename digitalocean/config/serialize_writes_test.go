@@ -0,0 +1,79 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SerializeWrites(t *testing.T) {
+	const count = 5
+
+	var writes, maxWrites int32
+	var reads sync.WaitGroup
+	reads.Add(count)
+	readsArrived := make(chan struct{})
+	go func() {
+		reads.Wait()
+		close(readsArrived)
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			// Every read must be in flight at once to get past this point.
+			reads.Done()
+			select {
+			case <-readsArrived:
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			return
+		}
+
+		n := atomic.AddInt32(&writes, 1)
+		for {
+			max := atomic.LoadInt32(&maxWrites)
+			if n <= max || atomic.CompareAndSwapInt32(&maxWrites, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&writes, -1)
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.SerializeWrites = true
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*count)
+	for i := 0; i < count; i++ {
+		for _, method := range []string{http.MethodPost, http.MethodGet} {
+			wg.Add(1)
+			go func(method string) {
+				defer wg.Done()
+				_, err := doRequest(t, client.GodoClient(), method, "/v2/droplets")
+				errs <- err
+			}(method)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if n := atomic.LoadInt32(&maxWrites); n != 1 {
+		t.Fatalf("Expected a single write in flight at a time, got %d", n)
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrSoftDeadlineExceeded is returned for requests that would be retried
+// after the soft deadline set with WithSoftDeadline.
+var ErrSoftDeadlineExceeded = errors.New("soft deadline exceeded")
+
+type softDeadlineKey struct{}
+
+// WithSoftDeadline returns a context that bounds the time spent retrying the
+// requests made with it by deadline. Unlike a context deadline, it does not
+// cancel an attempt in progress: the backoff between attempts is shortened
+// so that the next attempt starts by deadline, and a retry due once deadline
+// has passed fails at once with ErrSoftDeadlineExceeded instead of sleeping.
+func WithSoftDeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, softDeadlineKey{}, deadline)
+}
+
+// softDeadlineFrom returns the soft deadline attached to ctx, if any.
+func softDeadlineFrom(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(softDeadlineKey{}).(time.Time)
+	return deadline, ok
+}
+
+// clampToSoftDeadline caps sleep by the time left until the soft deadline of
+// the request of resp, if any.
+func clampToSoftDeadline(sleep time.Duration, resp *http.Response) time.Duration {
+	if resp == nil || resp.Request == nil {
+		return sleep
+	}
+
+	deadline, ok := softDeadlineFrom(resp.Request.Context())
+	if !ok {
+		return sleep
+	}
+
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	if sleep > remaining {
+		return remaining
+	}
+	return sleep
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff_SoftDeadline(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+
+	cases := []struct {
+		Name     string
+		Deadline time.Duration
+		Set      bool
+		Expected time.Duration
+		Delta    time.Duration
+	}{
+		{Name: "unset", Expected: 8 * time.Second},
+		{Name: "within the deadline", Set: true, Deadline: time.Minute, Expected: 8 * time.Second},
+		{Name: "clamped", Set: true, Deadline: 2 * time.Second, Expected: 2 * time.Second, Delta: 100 * time.Millisecond},
+		{Name: "passed", Set: true, Deadline: -time.Second, Expected: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.Set {
+				ctx = WithSoftDeadline(ctx, time.Now().Add(tc.Deadline))
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.digitalocean.com/v2/account", nil)
+			resp := testResponse(http.StatusServiceUnavailable, nil)
+			resp.Request = req
+
+			sleep := newBackoffPolicy(&Config{}).Backoff(min, max, 3, resp)
+			if diff := sleep - tc.Expected; diff < -tc.Delta || diff > tc.Delta {
+				t.Fatalf("Expected a sleep of %s, got %s", tc.Expected, sleep)
+			}
+		})
+	}
+}
+
+func TestClient_SoftDeadline(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.HTTPRetryWaitMin = 10
+	conf.HTTPRetryWaitMax = 10
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	ctx := WithSoftDeadline(context.Background(), time.Now().Add(200*time.Millisecond))
+	req, err := client.GodoClient().NewRequest(ctx, http.MethodGet, "/v2/account", nil)
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+
+	start := time.Now()
+	_, err = client.GodoClient().Do(ctx, req, nil)
+	if !errors.Is(err, ErrSoftDeadlineExceeded) {
+		t.Fatalf("Expected ErrSoftDeadlineExceeded, got %v", err)
+	}
+
+	// The 10 second backoff is shortened to the deadline, after which the
+	// next retry fails at once.
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Expected the request to fail by the deadline, took %s", elapsed)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", n)
+	}
+}
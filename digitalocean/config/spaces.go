@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// errSpacesCredentialsMissing is returned when Spaces are used without
+// credentials.
+var errSpacesCredentialsMissing = errors.New("Spaces credentials not configured: set spaces_access_id and " +
+	"spaces_secret_key in the provider configuration, or the SPACES_ACCESS_KEY_ID and " +
+	"SPACES_SECRET_ACCESS_KEY environment variables")
+
+func (c *CombinedConfig) SpacesClient(region string) (*session.Session, error) {
+	if c.accessID == "" || c.secretKey == "" {
+		return &session.Session{}, errSpacesCredentialsMissing
+	}
+
+	endpoint, err := c.spacesEndpoints.endpoint(region)
+	if err != nil {
+		return &session.Session{}, err
+	}
+
+	awsConfig := &aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials(c.accessID, c.secretKey, ""),
+		Endpoint:    aws.String(endpoint),
+	}
+
+	if c.spacesUseDualStack {
+		awsConfig.UseDualStack = aws.Bool(true)
+	}
+
+	if c.spacesRetryer != nil {
+		awsConfig.Retryer = c.spacesRetryer
+	}
+
+	opts := session.Options{}
+	if c.spacesSessionOptions != nil {
+		opts = *c.spacesSessionOptions
+	}
+	opts.Config.MergeIn(awsConfig)
+
+	client, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return &session.Session{}, err
+	}
+
+	if c.spacesCompressMinSize > 0 {
+		client.Handlers.Build.PushBackNamed(compressUploadsHandler(c.spacesCompressMinSize))
+	}
+
+	// Wrap the transport once the session is built so that any transport
+	// customization done by the SDK, such as loading a CA bundle, is kept.
+	client.Config.HTTPClient = wrapHTTPClient(client.Config.HTTPClient, c.enforceSpacesMinTLSVersion)
+	if c.spacesUploadLimiter != nil {
+		client.Config.HTTPClient = wrapHTTPClient(client.Config.HTTPClient, func(base http.RoundTripper) http.RoundTripper {
+			return &uploadThrottleTransport{base: base, limiter: c.spacesUploadLimiter}
+		})
+	}
+
+	return client, nil
+}
+
+// enforceSpacesMinTLSVersion returns a transport equivalent to base that
+// does not negotiate a TLS version below the configured minimum. Copies of a
+// transport are shared by sessions, so that connections are reused.
+func (c *CombinedConfig) enforceSpacesMinTLSVersion(base http.RoundTripper) http.RoundTripper {
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return base
+	}
+	if transport.TLSClientConfig != nil && transport.TLSClientConfig.MinVersion >= c.minTLSVersion {
+		return base
+	}
+
+	if enforced, ok := c.spacesTransports.Load(transport); ok {
+		return enforced.(*http.Transport)
+	}
+
+	enforced := transport.Clone()
+	enforced.TLSClientConfig = withMinTLSVersion(enforced.TLSClientConfig, c.minTLSVersion)
+	actual, _ := c.spacesTransports.LoadOrStore(transport, enforced)
+	return actual.(*http.Transport)
+}
+
+// wrapHTTPClient returns a shallow copy of client whose transport has been
+// wrapped by wrap. A nil client or transport falls back to the defaults.
+func wrapHTTPClient(client *http.Client, wrap func(http.RoundTripper) http.RoundTripper) *http.Client {
+	wrapped := &http.Client{}
+	if client != nil {
+		*wrapped = *client
+	}
+
+	base := wrapped.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped.Transport = wrap(base)
+
+	return wrapped
+}
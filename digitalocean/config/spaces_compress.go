@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// defaultSpacesCompressThreshold is the body size from which Spaces uploads
+// are compressed when SpacesCompressUploads is set.
+const defaultSpacesCompressThreshold = 64 * 1024
+
+// compressedContentTypes are content types whose payload is already
+// compressed and does not benefit from gzip.
+var compressedContentTypes = []string{
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"audio/",
+	"video/",
+}
+
+// compressUploadsHandler returns a request handler that gzips the body of
+// PutObject requests of at least threshold bytes and sets their
+// Content-Encoding. It must run before the request is marshaled so that the
+// compressed body is the one hashed and signed.
+func compressUploadsHandler(threshold int64) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "digitalocean.CompressUploads",
+		Fn: func(r *request.Request) {
+			if r.Operation.Name != "PutObject" || r.IsPresigned() {
+				return
+			}
+
+			input, ok := r.Params.(*s3.PutObjectInput)
+			if !ok || !compressibleUpload(input) {
+				return
+			}
+
+			compressed, ok, err := gzipBody(input.Body, threshold)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			if !ok {
+				return
+			}
+
+			// Work on a copy so that the caller's input is left untouched.
+			in := *input
+			in.Body = bytes.NewReader(compressed)
+			in.ContentEncoding = aws.String("gzip")
+			if in.ContentLength != nil {
+				in.ContentLength = aws.Int64(int64(len(compressed)))
+			}
+			r.Params = &in
+		},
+	}
+}
+
+// compressibleUpload reports whether the object may be stored gzipped: it
+// must not already be encoded or carry a checksum of the original body.
+func compressibleUpload(input *s3.PutObjectInput) bool {
+	if input.Body == nil || aws.StringValue(input.ContentEncoding) != "" || aws.StringValue(input.ContentMD5) != "" {
+		return false
+	}
+
+	contentType := strings.ToLower(aws.StringValue(input.ContentType))
+	for _, compressed := range compressedContentTypes {
+		if strings.HasPrefix(contentType, compressed) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// gzipBody compresses body when it holds at least threshold bytes. It reports
+// false, leaving body rewound, when the body is too small or does not shrink.
+func gzipBody(body io.ReadSeeker, threshold int64) ([]byte, bool, error) {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, false, err
+	}
+	end, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := body.Seek(start, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+
+	size := end - start
+	if size < threshold {
+		return nil, false, nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.Copy(w, body); err != nil {
+		return nil, false, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, false, err
+	}
+
+	if int64(buf.Len()) >= size {
+		_, err := body.Seek(start, io.SeekStart)
+		return nil, false, err
+	}
+
+	return buf.Bytes(), true, nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestSpacesCompressUploads(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Enabled          bool
+		Size             int
+		ContentType      string
+		ExpectCompressed bool
+	}{
+		{
+			Name: "disabled",
+			Size: 100000,
+		},
+		{
+			Name:             "large body",
+			Enabled:          true,
+			Size:             100000,
+			ExpectCompressed: true,
+		},
+		{
+			Name:    "below threshold",
+			Enabled: true,
+			Size:    1000,
+		},
+		{
+			Name:        "already compressed",
+			Enabled:     true,
+			Size:        100000,
+			ContentType: "application/zip",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var encoding string
+			var received []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding = r.Header.Get("Content-Encoding")
+				received, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			conf := testConfig(server.URL)
+			conf.SpacesAPIEndpoint = server.URL
+			conf.AccessID = "access"
+			conf.SecretKey = "secret"
+			conf.SpacesCompressUploads = tc.Enabled
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			sess, err := client.SpacesClient("nyc3")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			body := bytes.Repeat([]byte("terraform "), tc.Size/10)
+			input := &s3.PutObjectInput{
+				Bucket: aws.String("bucket"),
+				Key:    aws.String("object"),
+				Body:   bytes.NewReader(body),
+			}
+			if tc.ContentType != "" {
+				input.ContentType = aws.String(tc.ContentType)
+			}
+
+			svc := s3.New(sess, &aws.Config{S3ForcePathStyle: aws.Bool(true)})
+			if _, err := svc.PutObject(input); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !tc.ExpectCompressed {
+				if encoding != "" || !bytes.Equal(received, body) {
+					t.Fatalf("Expected the body to be sent as is, got %d bytes with encoding %q", len(received), encoding)
+				}
+				return
+			}
+
+			if encoding != "gzip" {
+				t.Fatalf("Expected a gzip Content-Encoding, got %q", encoding)
+			}
+			if len(received) >= len(body) {
+				t.Fatalf("Expected the body to shrink, got %d bytes", len(received))
+			}
+
+			r, err := gzip.NewReader(bytes.NewReader(received))
+			if err != nil {
+				t.Fatalf("Expected a gzip body, got %s", err)
+			}
+			decompressed, _ := io.ReadAll(r)
+			if !bytes.Equal(decompressed, body) {
+				t.Fatalf("Expected the decompressed body to match the original")
+			}
+			if input.ContentEncoding != nil {
+				t.Fatalf("Expected the caller's input to be left untouched")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// headerBucketRegion names the region of a bucket in responses to requests
+// sent to another region.
+const headerBucketRegion = "X-Amz-Bucket-Region"
+
+// DiscoverSpacesRegion returns the region of bucket. Known Spaces regions are
+// probed one at a time with a HEAD request, stopping as soon as the bucket is
+// found or a region redirects to the bucket's region.
+func (c *CombinedConfig) DiscoverSpacesRegion(ctx context.Context, bucket string) (string, error) {
+	for _, region := range spacesRegions {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		found, redirect, err := c.probeSpacesBucket(ctx, region, bucket)
+		if err != nil {
+			return "", err
+		}
+		if redirect != "" && ValidSpacesRegion(redirect) {
+			return strings.ToLower(redirect), nil
+		}
+		if found {
+			return region, nil
+		}
+	}
+
+	return "", fmt.Errorf("bucket %q was not found in any Spaces region (%s)", bucket, strings.Join(spacesRegions, ", "))
+}
+
+// probeSpacesBucket sends a HEAD request for bucket to region. It reports
+// whether the bucket exists there, and the region it is redirected to, if
+// any. A bucket that exists but is not accessible counts as found.
+func (c *CombinedConfig) probeSpacesBucket(ctx context.Context, region, bucket string) (bool, string, error) {
+	sess, err := c.SpacesClient(region)
+	if err != nil {
+		return false, "", err
+	}
+
+	req, _ := s3.New(sess).HeadBucketRequest(&s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	req.SetContext(ctx)
+	err = req.Send()
+
+	var redirect string
+	status := 0
+	if req.HTTPResponse != nil {
+		redirect = req.HTTPResponse.Header.Get(headerBucketRegion)
+		status = req.HTTPResponse.StatusCode
+	}
+
+	switch {
+	case err == nil:
+		return true, redirect, nil
+	case redirect != "" || status == http.StatusNotFound || status == http.StatusMovedPermanently:
+		return false, redirect, nil
+	case status == http.StatusForbidden:
+		return true, redirect, nil
+	case ctx.Err() != nil:
+		return false, "", ctx.Err()
+	}
+
+	return false, "", fmt.Errorf("probing bucket %q in %s: %w", bucket, region, err)
+}
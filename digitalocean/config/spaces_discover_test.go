@@ -0,0 +1,126 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// bucketRegionServer serves HEAD requests to /<region>/<bucket>, finding the
+// bucket in region only. Other regions redirect when redirect is set and
+// respond 404 otherwise.
+func bucketRegionServer(region string, redirect bool) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var probed []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probe, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		mu.Lock()
+		probed = append(probed, probe)
+		mu.Unlock()
+
+		switch {
+		case probe == region:
+			w.WriteHeader(http.StatusOK)
+		case redirect:
+			w.Header().Set(headerBucketRegion, region)
+			w.WriteHeader(http.StatusMovedPermanently)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), probed...)
+	}
+}
+
+func TestDiscoverSpacesRegion(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Redirect bool
+		Region   string
+		Expected string
+		Probed   []string
+	}{
+		{
+			Name:     "redirect",
+			Redirect: true,
+			Region:   "sgp1",
+			Expected: "sgp1",
+			Probed:   []string{"ams3"},
+		},
+		{
+			Name:     "probe known regions",
+			Region:   "nyc3",
+			Expected: "nyc3",
+			Probed:   []string{"ams3", "fra1", "nyc3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server, probed := bucketRegionServer(tc.Region, tc.Redirect)
+			defer server.Close()
+
+			client, err := testDownloadConfig(server.URL + "/{{.Region}}").Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			region, err := client.DiscoverSpacesRegion(context.Background(), "my-bucket")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if region != tc.Expected {
+				t.Fatalf("Expected region %s, got %s", tc.Expected, region)
+			}
+			if got := probed(); strings.Join(got, ",") != strings.Join(tc.Probed, ",") {
+				t.Fatalf("Expected to probe %v, got %v", tc.Probed, got)
+			}
+		})
+	}
+}
+
+func TestDiscoverSpacesRegion_NotFound(t *testing.T) {
+	server, probed := bucketRegionServer("", false)
+	defer server.Close()
+
+	client, err := testDownloadConfig(server.URL + "/{{.Region}}").Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err = client.DiscoverSpacesRegion(context.Background(), "my-bucket")
+	if err == nil || !strings.Contains(err.Error(), "was not found in any Spaces region") {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+	if n := len(probed()); n != len(SpacesRegions()) {
+		t.Fatalf("Expected every region to be probed, got %d", n)
+	}
+}
+
+func TestDiscoverSpacesRegion_Canceled(t *testing.T) {
+	server, probed := bucketRegionServer("nyc3", false)
+	defer server.Close()
+
+	client, err := testDownloadConfig(server.URL + "/{{.Region}}").Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.DiscoverSpacesRegion(ctx, "my-bucket"); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if n := len(probed()); n != 0 {
+		t.Fatalf("Expected no probes, got %d", n)
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// DownloadSpacesObject streams the object key in bucket to w. When the
+// download fails partway, it resumes from the last byte received using a
+// Range request, up to the number of retries configured for Spaces. Errors
+// returned by w are not retried.
+func (c *CombinedConfig) DownloadSpacesObject(ctx context.Context, region, bucket, key string, w io.Writer) error {
+	sess, err := c.SpacesClient(region)
+	if err != nil {
+		return err
+	}
+	svc := s3.New(sess)
+
+	maxResumes := client.DefaultRetryerMaxNumRetries
+	if c.spacesRetryer != nil {
+		maxResumes = c.spacesRetryer.MaxRetries()
+	}
+
+	var written int64
+	var etag *string
+	for resumes := 0; ; resumes++ {
+		input := &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		}
+		if written > 0 {
+			input.Range = aws.String(fmt.Sprintf("bytes=%d-", written))
+			// Fail rather than splice together two versions of the object.
+			input.IfMatch = etag
+		}
+
+		// Failures to get the object are already retried by the SDK.
+		out, err := svc.GetObjectWithContext(ctx, input)
+		if err != nil {
+			return fmt.Errorf("downloading %s/%s: %w", bucket, key, err)
+		}
+		if written > 0 && !strings.HasPrefix(aws.StringValue(out.ContentRange), fmt.Sprintf("bytes %d-", written)) {
+			out.Body.Close()
+			return fmt.Errorf("downloading %s/%s: unable to resume at byte %d, got content range %q",
+				bucket, key, written, aws.StringValue(out.ContentRange))
+		}
+		if etag == nil {
+			etag = out.ETag
+		}
+
+		body := &downloadReader{r: out.Body}
+		n, err := io.Copy(w, body)
+		out.Body.Close()
+		written += n
+
+		switch {
+		case err == nil:
+			return nil
+		case body.err == nil:
+			// The error was returned by w.
+			return err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case resumes >= maxResumes:
+			return fmt.Errorf("downloading %s/%s: giving up after %d bytes and %d retries: %w",
+				bucket, key, written, resumes, body.err)
+		}
+	}
+}
+
+// downloadReader records the error returned by the body of a download, to
+// tell it apart from errors returned by the writer.
+type downloadReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *downloadReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// flakyObjectServer serves object with support for ranges, closing the
+// connection of the first failures responses after failAfter bytes.
+func flakyObjectServer(t *testing.T, object []byte, failures int, failAfter int) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var ranges []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		attempt := len(ranges)
+		mu.Unlock()
+
+		start := 0
+		if rng := r.Header.Get("Range"); rng != "" {
+			var err error
+			start, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			if err != nil {
+				t.Errorf("unexpected range %q", rng)
+			}
+			if r.Header.Get("If-Match") != `"etag"` {
+				t.Errorf("Expected resumed requests to match the ETag, got %q", r.Header.Get("If-Match"))
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(object)-1, len(object)))
+		}
+
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)-start))
+		if r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+
+		if attempt > failures {
+			w.Write(object[start:])
+			return
+		}
+
+		w.Write(object[start : start+failAfter])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("unable to hijack connection: %s", err)
+			return
+		}
+		conn.Close()
+	}))
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func testDownloadConfig(endpoint string) *Config {
+	conf := testSpacesConfig()
+	conf.SpacesAPIEndpoint = endpoint
+	conf.SpacesHTTPRetryMax = -1
+	conf.SpacesSessionOptions = &session.Options{
+		Config: aws.Config{S3ForcePathStyle: aws.Bool(true)},
+	}
+	return conf
+}
+
+func TestDownloadSpacesObject_Resume(t *testing.T) {
+	object := bytes.Repeat([]byte("0123456789"), 10)
+	server, ranges := flakyObjectServer(t, object, 2, 30)
+	defer server.Close()
+
+	client, err := testDownloadConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := client.DownloadSpacesObject(context.Background(), "nyc3", "my-bucket", "object", &buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), object) {
+		t.Fatalf("Expected the full object, got %q", buf.String())
+	}
+	expected := []string{"", "bytes=30-", "bytes=60-"}
+	if got := ranges(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected ranges %q, got %q", expected, got)
+	}
+}
+
+func TestDownloadSpacesObject_GivesUp(t *testing.T) {
+	object := bytes.Repeat([]byte("0123456789"), 10)
+	server, ranges := flakyObjectServer(t, object, 10, 10)
+	defer server.Close()
+
+	conf := testDownloadConfig(server.URL)
+	conf.SpacesHTTPRetryMax = 2
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	err = client.DownloadSpacesObject(context.Background(), "nyc3", "my-bucket", "object", &buf)
+	if err == nil || !strings.Contains(err.Error(), "giving up after 30 bytes and 2 retries") {
+		t.Fatalf("Expected the download to give up, got %v", err)
+	}
+	if n := len(ranges()); n != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", n)
+	}
+}
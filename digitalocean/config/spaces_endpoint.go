@@ -0,0 +1,64 @@
+package config
+
+import (
+	"html/template"
+	"strings"
+	"sync"
+)
+
+// spacesEndpointCache renders the spaces_endpoint template once per region.
+// It is safe for concurrent use.
+type spacesEndpointCache struct {
+	tmpl *template.Template
+	// pristine is a copy of tmpl that is never executed, since html/template
+	// cannot clone a template once it has been executed.
+	pristine *template.Template
+
+	mu        sync.Mutex
+	endpoints map[string]string
+}
+
+func newSpacesEndpointCache(tmpl *template.Template) (*spacesEndpointCache, error) {
+	pristine, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return &spacesEndpointCache{tmpl: tmpl, pristine: pristine, endpoints: map[string]string{}}, nil
+}
+
+// template returns a copy of the template that the caller may modify.
+func (c *spacesEndpointCache) template() *template.Template {
+	// Cloning only fails for templates that have been executed.
+	return template.Must(c.pristine.Clone())
+}
+
+// endpoint returns the Spaces endpoint of region. Rendering errors are not
+// cached.
+func (c *spacesEndpointCache) endpoint(region string) (string, error) {
+	region = strings.ToLower(region)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if endpoint, ok := c.endpoints[region]; ok {
+		return endpoint, nil
+	}
+
+	endpointWriter := strings.Builder{}
+	err := c.tmpl.Execute(&endpointWriter, map[string]string{
+		"Region": region,
+	})
+	if err != nil {
+		return "", err
+	}
+	endpoint := endpointWriter.String()
+	c.endpoints[region] = endpoint
+
+	return endpoint, nil
+}
+
+// SpacesTemplate returns a copy of the parsed spaces_endpoint template, which
+// renders the endpoint of the Region variable.
+func (c *CombinedConfig) SpacesTemplate() *template.Template {
+	return c.spacesEndpoints.template()
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestSpacesEndpointCache(t *testing.T) {
+	renders := map[string]int{}
+	tmpl := template.Must(template.New("spaces").Funcs(template.FuncMap{
+		"count": func(region string) string {
+			renders[region]++
+			return region
+		},
+	}).Parse("https://{{count .Region}}.digitaloceanspaces.com"))
+
+	cache, err := newSpacesEndpointCache(tmpl)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, region := range []string{"nyc3", "NYC3", "ams3", "nyc3", "ams3"} {
+		if _, err := cache.endpoint(region); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	endpoint, err := cache.endpoint("ams3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if endpoint != "https://ams3.digitaloceanspaces.com" {
+		t.Fatalf("Expected the rendered endpoint, got %q", endpoint)
+	}
+	if len(renders) != 2 || renders["nyc3"] != 1 || renders["ams3"] != 1 {
+		t.Fatalf("Expected the endpoint to be rendered once per region, got %v", renders)
+	}
+}
+
+func TestCombinedConfig_SpacesTemplate(t *testing.T) {
+	client, err := testSpacesConfig().Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, region := range []string{"nyc3", "AMS3"} {
+		endpoint, err := client.spacesEndpoints.endpoint(region)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		rendered := strings.Builder{}
+		if err := client.SpacesTemplate().Execute(&rendered, map[string]string{"Region": strings.ToLower(region)}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if rendered.String() != endpoint {
+			t.Fatalf("Expected the template to render %q, got %q", endpoint, rendered.String())
+		}
+	}
+
+	// Changes to the returned copy must not affect the client.
+	if _, err := client.SpacesTemplate().Parse("https://example.com"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if endpoint, _ := client.spacesEndpoints.endpoint("fra1"); endpoint != "https://fra1.digitaloceanspaces.com" {
+		t.Fatalf("Expected the client's template to be left untouched, got %q", endpoint)
+	}
+}
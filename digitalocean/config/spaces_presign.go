@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// PresignSpacesURL returns a pre-signed URL granting GET or PUT access to the
+// object key in bucket for the given duration, signed with the configured
+// Spaces credentials.
+func (c *CombinedConfig) PresignSpacesURL(region, bucket, key, method string, expires time.Duration) (string, error) {
+	if expires <= 0 {
+		return "", fmt.Errorf("expiry of a pre-signed URL must be positive, got %s", expires)
+	}
+
+	sess, err := c.SpacesClient(region)
+	if err != nil {
+		return "", err
+	}
+	svc := s3.New(sess)
+
+	var req *request.Request
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		req, _ = svc.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	case http.MethodPut:
+		req, _ = svc.PutObjectRequest(&s3.PutObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	default:
+		return "", fmt.Errorf("unsupported method %q for a pre-signed URL, expected GET or PUT", method)
+	}
+
+	return req.Presign(expires)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresignSpacesURL(t *testing.T) {
+	client, err := testSpacesConfig().Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		t.Run(method, func(t *testing.T) {
+			presigned, err := client.PresignSpacesURL("NYC3", "my-bucket", "path/to/object.txt", method, 15*time.Minute)
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			u, err := url.Parse(presigned)
+			if err != nil {
+				t.Fatalf("Expected a valid URL, got %s", err)
+			}
+
+			if u.Host != "my-bucket.nyc3.digitaloceanspaces.com" {
+				t.Fatalf("Expected the bucket host, got %s", u.Host)
+			}
+			if u.Path != "/path/to/object.txt" {
+				t.Fatalf("Expected the object path, got %s", u.Path)
+			}
+
+			query := u.Query()
+			if query.Get("X-Amz-Expires") != "900" {
+				t.Fatalf("Expected an expiry of 900 seconds, got %q", query.Get("X-Amz-Expires"))
+			}
+			if query.Get("X-Amz-Signature") == "" {
+				t.Fatalf("Expected a signature, got %s", presigned)
+			}
+			if credential := query.Get("X-Amz-Credential"); !strings.HasPrefix(credential, "access/") {
+				t.Fatalf("Expected the credential to use the access ID, got %q", credential)
+			}
+		})
+	}
+}
+
+func TestPresignSpacesURL_Invalid(t *testing.T) {
+	client, err := testSpacesConfig().Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, err := client.PresignSpacesURL("nyc3", "my-bucket", "key", http.MethodDelete, time.Minute); err == nil {
+		t.Fatalf("Expected an error for an unsupported method")
+	}
+	if _, err := client.PresignSpacesURL("nyc3", "my-bucket", "key", http.MethodGet, 0); err == nil {
+		t.Fatalf("Expected an error for a non-positive expiry")
+	}
+}
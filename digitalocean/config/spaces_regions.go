@@ -0,0 +1,25 @@
+package config
+
+import "strings"
+
+// spacesRegions lists the DigitalOcean regions that support Spaces. It needs
+// to be updated as DigitalOcean adds regions.
+var spacesRegions = []string{"ams3", "fra1", "nyc3", "sfo2", "sfo3", "sgp1", "syd1"}
+
+// SpacesRegions returns the DigitalOcean regions that support Spaces.
+func SpacesRegions() []string {
+	regions := make([]string, len(spacesRegions))
+	copy(regions, spacesRegions)
+	return regions
+}
+
+// ValidSpacesRegion reports whether region, compared case-insensitively, is
+// a known Spaces region.
+func ValidSpacesRegion(region string) bool {
+	for _, r := range spacesRegions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
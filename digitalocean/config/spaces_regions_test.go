@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestValidSpacesRegion(t *testing.T) {
+	cases := []struct {
+		Region   string
+		Expected bool
+	}{
+		{Region: "nyc3", Expected: true},
+		{Region: "AMS3", Expected: true},
+		{Region: "Sfo3", Expected: true},
+		{Region: "nyc1", Expected: false},
+		{Region: "nyc", Expected: false},
+		{Region: "", Expected: false},
+	}
+
+	for _, tc := range cases {
+		if valid := ValidSpacesRegion(tc.Region); valid != tc.Expected {
+			t.Fatalf("Expected ValidSpacesRegion(%q) to be %t", tc.Region, tc.Expected)
+		}
+	}
+}
+
+func TestSpacesRegions_Copy(t *testing.T) {
+	regions := SpacesRegions()
+	regions[0] = "modified"
+
+	if SpacesRegions()[0] == "modified" {
+		t.Fatalf("Expected SpacesRegions to return a copy")
+	}
+}
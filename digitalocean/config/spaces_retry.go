@@ -0,0 +1,33 @@
+package config
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// newSpacesRetryer returns the retryer configured for Spaces sessions by the
+// SpacesHTTPRetry* fields of c, or nil to keep the SDK's default retryer.
+func newSpacesRetryer(c *Config) request.Retryer {
+	if c.SpacesHTTPRetryMax < 0 && c.SpacesHTTPRetryWaitMin <= 0 && c.SpacesHTTPRetryWaitMax <= 0 {
+		return nil
+	}
+
+	retryMax := c.SpacesHTTPRetryMax
+	if retryMax < 0 {
+		retryMax = client.DefaultRetryerMaxNumRetries
+	}
+
+	// Zero delays are replaced by the SDK defaults.
+	waitMin := time.Duration(c.SpacesHTTPRetryWaitMin * float64(time.Second))
+	waitMax := time.Duration(c.SpacesHTTPRetryWaitMax * float64(time.Second))
+
+	return client.DefaultRetryer{
+		NumMaxRetries:    retryMax,
+		MinRetryDelay:    waitMin,
+		MinThrottleDelay: waitMin,
+		MaxRetryDelay:    waitMax,
+		MaxThrottleDelay: waitMax,
+	}
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+func testSpacesConfig() *Config {
+	conf := testConfig("https://api.digitalocean.com")
+	conf.AccessID = "access"
+	conf.SecretKey = "secret"
+	return conf
+}
+
+func TestSpacesClient_DualStack(t *testing.T) {
+	cases := []struct {
+		Name      string
+		DualStack bool
+		Expected  *bool
+	}{
+		{Name: "default"},
+		{Name: "enabled", DualStack: true, Expected: aws.Bool(true)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			conf := testSpacesConfig()
+			conf.SpacesUseDualStack = tc.DualStack
+
+			client, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			sess, err := client.SpacesClient("nyc3")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := sess.Config.UseDualStack; (got == nil) != (tc.Expected == nil) || (got != nil && *got != *tc.Expected) {
+				t.Fatalf("Expected UseDualStack to be %v, got %v", aws.BoolValue(tc.Expected), aws.BoolValue(got))
+			}
+		})
+	}
+}
+
+func TestSpacesClient_SessionOptions(t *testing.T) {
+	conf := testSpacesConfig()
+	conf.SpacesSessionOptions = &session.Options{
+		Config: aws.Config{
+			MaxRetries: aws.Int(7),
+			Region:     aws.String("eu-west-1"),
+			Endpoint:   aws.String("https://example.com"),
+		},
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sess, err := client.SpacesClient("nyc3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := aws.IntValue(sess.Config.MaxRetries); got != 7 {
+		t.Errorf("Expected the user supplied MaxRetries to survive, got %d", got)
+	}
+	if got := aws.StringValue(sess.Config.Region); got != "us-east-1" {
+		t.Errorf("Expected region us-east-1 to take precedence, got %s", got)
+	}
+	if got := aws.StringValue(sess.Config.Endpoint); got != "https://nyc3.digitaloceanspaces.com" {
+		t.Errorf("Expected the Spaces endpoint to take precedence, got %s", got)
+	}
+
+	// The caller's options must not be modified by the merge.
+	if got := aws.StringValue(conf.SpacesSessionOptions.Config.Region); got != "eu-west-1" {
+		t.Errorf("Expected the supplied options to be left untouched, got region %s", got)
+	}
+}
+
+func TestSpacesClient_MinTLSVersion(t *testing.T) {
+	conf := testSpacesConfig()
+	conf.MinTLSVersion = tls.VersionTLS13
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var transports []*http.Transport
+	for _, region := range []string{"nyc3", "ams3"} {
+		sess, err := client.SpacesClient(region)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		transport, ok := sess.Config.HTTPClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("Expected an *http.Transport, got %T", sess.Config.HTTPClient.Transport)
+		}
+		if transport.TLSClientConfig == nil || transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+			t.Fatalf("Expected a minimum TLS version of TLS 1.3, got %+v", transport.TLSClientConfig)
+		}
+		transports = append(transports, transport)
+	}
+
+	if transports[0] != transports[1] {
+		t.Fatalf("Expected sessions to share a transport")
+	}
+	if tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig; tlsConfig != nil && tlsConfig.MinVersion == tls.VersionTLS13 {
+		t.Fatalf("Expected the default transport to be left untouched")
+	}
+}
+
+func TestSpacesRequired(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Required    bool
+		Credentials bool
+		ClientErr   bool
+		SpacesErr   bool
+	}{
+		{
+			Name:      "lazy without credentials",
+			SpacesErr: true,
+		},
+		{
+			Name:      "required without credentials",
+			Required:  true,
+			ClientErr: true,
+		},
+		{
+			Name:        "required with credentials",
+			Required:    true,
+			Credentials: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			conf := testConfig("https://api.digitalocean.com")
+			conf.SpacesRequired = tc.Required
+			if tc.Credentials {
+				conf.AccessID = "access"
+				conf.SecretKey = "secret"
+			}
+
+			client, err := conf.Client()
+			if tc.ClientErr {
+				if err != errSpacesCredentialsMissing {
+					t.Fatalf("Expected errSpacesCredentialsMissing from Client(), got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			_, err = client.SpacesClient("nyc3")
+			if tc.SpacesErr {
+				if err != errSpacesCredentialsMissing {
+					t.Fatalf("Expected errSpacesCredentialsMissing from SpacesClient(), got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestSpacesClient_Retryer(t *testing.T) {
+	cases := []struct {
+		Name     string
+		RetryMax int
+		WaitMin  float64
+		WaitMax  float64
+		Expected client.DefaultRetryer
+	}{
+		{
+			Name:     "custom",
+			RetryMax: 7,
+			WaitMin:  0.5,
+			WaitMax:  10,
+			Expected: client.DefaultRetryer{
+				NumMaxRetries:    7,
+				MinRetryDelay:    500 * time.Millisecond,
+				MinThrottleDelay: 500 * time.Millisecond,
+				MaxRetryDelay:    10 * time.Second,
+				MaxThrottleDelay: 10 * time.Second,
+			},
+		},
+		{
+			Name:     "retry max only",
+			RetryMax: 1,
+			Expected: client.DefaultRetryer{NumMaxRetries: 1},
+		},
+		{
+			Name:     "disabled",
+			RetryMax: 0,
+			Expected: client.DefaultRetryer{NumMaxRetries: 0},
+		},
+		{
+			Name:     "waits only",
+			RetryMax: -1,
+			WaitMax:  2,
+			Expected: client.DefaultRetryer{
+				NumMaxRetries:    client.DefaultRetryerMaxNumRetries,
+				MaxRetryDelay:    2 * time.Second,
+				MaxThrottleDelay: 2 * time.Second,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			conf := testSpacesConfig()
+			conf.SpacesHTTPRetryMax = tc.RetryMax
+			conf.SpacesHTTPRetryWaitMin = tc.WaitMin
+			conf.SpacesHTTPRetryWaitMax = tc.WaitMax
+
+			combined, err := conf.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			sess, err := combined.SpacesClient("nyc3")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			retryer, ok := sess.Config.Retryer.(client.DefaultRetryer)
+			if !ok {
+				t.Fatalf("Expected a client.DefaultRetryer, got %T", sess.Config.Retryer)
+			}
+			if retryer != tc.Expected {
+				t.Fatalf("Expected %+v, got %+v", tc.Expected, retryer)
+			}
+		})
+	}
+}
+
+func TestSpacesClient_DefaultRetryer(t *testing.T) {
+	conf := testSpacesConfig()
+	conf.SpacesHTTPRetryMax = -1
+
+	combined, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sess, err := combined.SpacesClient("nyc3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if sess.Config.Retryer != nil {
+		t.Fatalf("Expected the SDK default retryer, got %T", sess.Config.Retryer)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// maxUploadChunkSize bounds how many bytes are released to the network per
+// limiter wait when Spaces upload throttling is enabled.
+const maxUploadChunkSize = 32 * 1024
+
+// newUploadLimiter returns a limiter capping upload throughput at
+// bytesPerSecond, or nil when throttling is disabled.
+func newUploadLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	burst := maxUploadChunkSize
+	if bytesPerSecond < int64(burst) {
+		burst = int(bytesPerSecond)
+	}
+
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// uploadThrottleTransport caps the throughput of request bodies sent through
+// it. It is distinct from the request rate limit applied to the API client.
+type uploadThrottleTransport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+func (t *uploadThrottleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = &throttledReader{
+		ctx:     req.Context(),
+		body:    req.Body,
+		limiter: t.limiter,
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// throttledReader waits on the limiter for every chunk read from body.
+type throttledReader struct {
+	ctx     context.Context
+	body    io.ReadCloser
+	limiter *rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+
+	n, err := r.body.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+func (r *throttledReader) Close() error {
+	return r.body.Close()
+}
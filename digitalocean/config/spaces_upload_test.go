@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestSpacesUploadBytesPerSecond(t *testing.T) {
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		received = n
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.SpacesAPIEndpoint = server.URL
+	conf.AccessID = "access"
+	conf.SecretKey = "secret"
+	conf.SpacesUploadBytesPerSecond = 10000
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sess, err := client.SpacesClient("nyc3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	body := bytes.Repeat([]byte("a"), 20000)
+	svc := s3.New(sess, &aws.Config{S3ForcePathStyle: aws.Bool(true)})
+
+	start := time.Now()
+	_, err = svc.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("object"),
+		Body:   bytes.NewReader(body),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	elapsed := time.Since(start)
+
+	if received != int64(len(body)) {
+		t.Fatalf("Expected %d bytes to be uploaded, got %d", len(body), received)
+	}
+
+	// The first 10000 bytes fit in the initial burst, the rest take a second.
+	if elapsed < 900*time.Millisecond {
+		t.Fatalf("Expected the upload to take at least 900ms, took %s", elapsed)
+	}
+}
+
+func TestSpacesUploadBytesPerSecond_Disabled(t *testing.T) {
+	if newUploadLimiter(0) != nil {
+		t.Fatal("Expected no limiter when throttling is disabled")
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RunSummary digests the activity of a client since it was built.
+type RunSummary struct {
+	// Requests is the number of requests sent, not counting retries.
+	Requests int64
+	// Retries is the number of retried attempts.
+	Retries int64
+	// RateLimited is the number of 429 Too Many Requests responses.
+	RateLimited int64
+	// Backoff is the total time scheduled to wait between retries.
+	Backoff time.Duration
+	// BytesTransferred counts the request and response body bytes of every
+	// attempt.
+	BytesTransferred int64
+	// PeakConcurrency is the largest number of requests in flight at once.
+	PeakConcurrency int
+}
+
+// String formats s on a single line.
+func (s RunSummary) String() string {
+	return fmt.Sprintf("requests=%d retries=%d rate_limited=%d backoff=%s bytes=%d peak_concurrency=%d",
+		s.Requests, s.Retries, s.RateLimited, s.Backoff, s.BytesTransferred, s.PeakConcurrency)
+}
+
+// summaryTransport counts the rate limited responses and the bytes
+// transferred by every attempt, reporting rate limited responses to metrics
+// too. It sits below the retrying transport.
+type summaryTransport struct {
+	base     http.RoundTripper
+	counters *progressCounters
+	metrics  MetricsSink
+}
+
+func (t *summaryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &countingReadCloser{ReadCloser: req.Body, count: &t.counters.bytes}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		atomic.AddInt64(&t.counters.rateLimited, 1)
+		if t.metrics != nil {
+			t.metrics.RateLimited()
+		}
+	}
+	if resp.Body != nil && resp.Body != http.NoBody {
+		resp.Body = &countingReadCloser{ReadCloser: resp.Body, count: &t.counters.bytes}
+	}
+	return resp, nil
+}
+
+// Summary returns the activity of the client since it was built.
+func (c *CombinedConfig) Summary() RunSummary {
+	snapshot := c.counters.snapshot()
+	return RunSummary{
+		Requests:         snapshot.Requests,
+		Retries:          snapshot.Retries,
+		RateLimited:      atomic.LoadInt64(&c.counters.rateLimited),
+		Backoff:          snapshot.Backoff,
+		BytesTransferred: atomic.LoadInt64(&c.counters.bytes),
+		PeakConcurrency:  c.inFlight.peakCount(),
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCombinedConfig_Summary(t *testing.T) {
+	const concurrent = 3
+
+	// Concurrent reads are held until they have all arrived.
+	var arrived sync.WaitGroup
+	arrived.Add(concurrent)
+	release := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(release)
+	}()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			arrived.Done()
+			<-release
+			return
+		}
+
+		io.Copy(io.Discard, r.Body)
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			io.WriteString(w, `{"id":"too_many_requests","message":"slow down"}`)
+			return
+		}
+		io.WriteString(w, `{"droplet":{}}`)
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer client.Close()
+
+	godoClient := client.GodoClient()
+	req, err := godoClient.NewRequest(context.Background(), http.MethodPost, "/v2/droplets", map[string]string{"name": "web"})
+	if err != nil {
+		t.Fatalf("unable to build request: %s", err)
+	}
+	if _, err := godoClient.Do(context.Background(), req, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The request body, `{"name":"web"}` and a newline, is sent twice.
+	expectedBytes := int64(2*15 + len(`{"id":"too_many_requests","message":"slow down"}`) + len(`{"droplet":{}}`))
+
+	summary := client.Summary()
+	if summary.Requests != 1 || summary.Retries != 1 || summary.RateLimited != 1 {
+		t.Fatalf("Expected 1 request, 1 retry and 1 rate limited response, got %+v", summary)
+	}
+	if summary.Backoff <= 0 {
+		t.Fatalf("Expected a backoff, got %s", summary.Backoff)
+	}
+	if summary.BytesTransferred != expectedBytes {
+		t.Fatalf("Expected %d bytes transferred, got %d", expectedBytes, summary.BytesTransferred)
+	}
+	if summary.PeakConcurrency != 1 {
+		t.Fatalf("Expected a peak concurrency of 1, got %d", summary.PeakConcurrency)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			doRequest(t, godoClient, http.MethodGet, "/v2/account")
+		}()
+	}
+	wg.Wait()
+
+	if peak := client.Summary().PeakConcurrency; peak != concurrent {
+		t.Fatalf("Expected a peak concurrency of %d, got %d", concurrent, peak)
+	}
+}
+
+func TestCombinedConfig_LogSummaryOnClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.LogSummaryOnClose = true
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	client.Close()
+	client.Close()
+
+	if n := strings.Count(output.String(), "run summary"); n != 1 {
+		t.Fatalf("Expected the summary to be logged once, got %q", output.String())
+	}
+	if !strings.Contains(output.String(), "requests=1 retries=0 rate_limited=0") {
+		t.Fatalf("Expected the summary to be logged, got %q", output.String())
+	}
+}
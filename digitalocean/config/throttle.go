@@ -0,0 +1,321 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ThrottleAlgorithm selects how RequestsPerSecond is enforced.
+type ThrottleAlgorithm string
+
+const (
+	// TokenBucket allows up to RequestsBurst requests at once, refilling at
+	// RequestsPerSecond. This is the default.
+	TokenBucket ThrottleAlgorithm = "token_bucket"
+
+	// LeakyBucket strictly spaces requests by 1/RequestsPerSecond, keeping a
+	// steady cadence regardless of how long the client was idle.
+	LeakyBucket ThrottleAlgorithm = "leaky_bucket"
+)
+
+// requestLimiter is implemented by the client-side throttles.
+type requestLimiter interface {
+	WaitN(ctx context.Context, n int) error
+}
+
+// newRequestLimiter builds the throttle configured on c, or returns nil when
+// no client-side rate limit is set.
+func newRequestLimiter(c *Config) (requestLimiter, error) {
+	return newLimiter(c.ThrottleAlgorithm, c.RequestsPerSecond, c.RequestsBurst)
+}
+
+// newLimiter builds a limiter of the given algorithm, or returns nil when
+// requestsPerSecond is not positive.
+func newLimiter(algorithm ThrottleAlgorithm, requestsPerSecond float64, burst int) (requestLimiter, error) {
+	if requestsPerSecond <= 0.0 {
+		return nil, nil
+	}
+
+	switch algorithm {
+	case "", TokenBucket:
+		if burst < 1 {
+			burst = 1
+		}
+		return rate.NewLimiter(rate.Limit(requestsPerSecond), burst), nil
+	case LeakyBucket:
+		return newLeakyBucket(requestsPerSecond), nil
+	}
+
+	return nil, fmt.Errorf("unknown throttle algorithm %q, expected %q or %q", algorithm, TokenBucket, LeakyBucket)
+}
+
+// newKeyedLimiters builds one limiter per key of limits.
+func newKeyedLimiters(algorithm ThrottleAlgorithm, limits map[string]float64, normalize func(string) string) (map[string]requestLimiter, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	limiters := make(map[string]requestLimiter, len(limits))
+	for key, requestsPerSecond := range limits {
+		limiter, err := newLimiter(algorithm, requestsPerSecond, 1)
+		if err != nil {
+			return nil, err
+		}
+		if limiter != nil {
+			limiters[normalize(key)] = limiter
+		}
+	}
+
+	return limiters, nil
+}
+
+// serviceName returns the API collection targeted by path, e.g. "droplets"
+// for "/v2/droplets/123/actions".
+func serviceName(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimPrefix(path, "v2/")
+
+	service, _, _ := strings.Cut(path, "/")
+	return service
+}
+
+// leakyBucket releases requests at a fixed interval with no bursting.
+type leakyBucket struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newLeakyBucket(requestsPerSecond float64) *leakyBucket {
+	return &leakyBucket{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+// WaitN blocks until the bucket has drained enough for n requests.
+func (b *leakyBucket) WaitN(ctx context.Context, n int) error {
+	now := time.Now()
+
+	b.mu.Lock()
+	slot := b.next
+	if slot.Before(now) {
+		slot = now
+	}
+	b.next = slot.Add(time.Duration(n) * b.interval)
+	b.mu.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// requestCost returns the cost of the longest path prefix of costs matching
+// path, or 1 when none matches.
+func requestCost(costs map[string]int, path string) int {
+	cost, matched := 1, -1
+	for prefix, c := range costs {
+		if len(prefix) > matched && strings.HasPrefix(path, prefix) {
+			cost, matched = c, len(prefix)
+		}
+	}
+	return cost
+}
+
+// validateRequestCosts checks that every cost can be satisfied by the global
+// limiter of c.
+func validateRequestCosts(c *Config) error {
+	burst := c.RequestsBurst
+	if burst < 1 {
+		burst = 1
+	}
+
+	for prefix, cost := range c.RequestCosts {
+		if cost < 1 {
+			return fmt.Errorf("request cost of %q must be at least 1, got %d", prefix, cost)
+		}
+		tokenBucket := c.ThrottleAlgorithm == "" || c.ThrottleAlgorithm == TokenBucket
+		if tokenBucket && c.RequestsPerSecond > 0 && cost > burst {
+			return fmt.Errorf("request cost of %q (%d) exceeds RequestsBurst (%d)", prefix, cost, burst)
+		}
+	}
+
+	return nil
+}
+
+// throttleTransport waits on the client-side limiters before each request:
+// the global limiter first, then the limiters of the request's service and
+// method, if any. Requests take as many tokens of the global limiter as their
+// cost. With a scheduler, requests wait on the global limiter in order of
+// priority and, with fair queuing, in proportion to the weight of their
+// workspace. Finally, requests acquire the distributed limiter, if any, for
+// the duration of the request.
+type throttleTransport struct {
+	base        http.RoundTripper
+	limiter     requestLimiter
+	scheduler   *priorityScheduler
+	services    map[string]requestLimiter
+	methods     map[string]requestLimiter
+	costs       map[string]int
+	distributed DistributedLimiter
+}
+
+// newThrottleTransport wraps base with the throttles configured on c. It
+// returns base unchanged when no client-side rate limit is configured.
+func newThrottleTransport(c *Config, base http.RoundTripper) (http.RoundTripper, error) {
+	if err := validateRequestCosts(c); err != nil {
+		return nil, err
+	}
+
+	limiter, err := newRequestLimiter(c)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := newKeyedLimiters(c.ThrottleAlgorithm, c.ServiceRequestsPerSecond, strings.ToLower)
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := newKeyedLimiters(c.ThrottleAlgorithm, c.MethodRequestsPerSecond, strings.ToUpper)
+	if err != nil {
+		return nil, err
+	}
+
+	if limiter == nil && len(services) == 0 && len(methods) == 0 && c.DistributedLimiter == nil {
+		return base, nil
+	}
+
+	var scheduler *priorityScheduler
+	if limiter != nil && (c.PriorityScheduling || c.WorkspaceFairQueuing) {
+		scheduler = &priorityScheduler{}
+		if c.WorkspaceFairQueuing {
+			scheduler.fair = newFairQueue(c.WorkspaceWeights)
+		}
+	}
+
+	return &throttleTransport{
+		base:        base,
+		limiter:     limiter,
+		scheduler:   scheduler,
+		services:    services,
+		methods:     methods,
+		costs:       c.RequestCosts,
+		distributed: c.DistributedLimiter,
+	}, nil
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	if t.limiter != nil {
+		if err := t.waitGlobal(req); err != nil {
+			return nil, err
+		}
+	}
+
+	limiters := []requestLimiter{
+		t.services[serviceName(req.URL.Path)],
+		t.methods[req.Method],
+	}
+
+	for _, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+		if err := limiter.WaitN(req.Context(), 1); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.distributed != nil {
+		if err := t.distributed.Acquire(req.Context(), requestCost(t.costs, req.URL.Path)); err != nil {
+			return nil, err
+		}
+		defer t.distributed.Release()
+	}
+
+	if state := requestStateFrom(req.Context()); state != nil {
+		state.mu.Lock()
+		state.throttleWait += time.Since(start)
+		state.mu.Unlock()
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// waitGlobal waits on the global limiter, taking turns with the other
+// requests by priority when a scheduler is set.
+func (t *throttleTransport) waitGlobal(req *http.Request) error {
+	ctx := req.Context()
+	cost := requestCost(t.costs, req.URL.Path)
+
+	if t.scheduler != nil {
+		if err := t.scheduler.acquire(ctx, priorityFrom(ctx), workspaceFrom(ctx), cost); err != nil {
+			return err
+		}
+		defer t.scheduler.release()
+	}
+
+	return t.limiter.WaitN(ctx, cost)
+}
+
+// ThrottleWait returns how long the request of resp waited on the
+// client-side limiters before being sent, as opposed to the time spent
+// backing off or on the network. It returns zero for requests that were not
+// throttled and for responses that were not returned by a client built by
+// Config.Client.
+func ThrottleWait(resp *http.Response) time.Duration {
+	state := responseState(resp)
+	if state == nil {
+		return 0
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.throttleWait
+}
+
+// Reserve takes n tokens from the client-side limiter that throttles the
+// requests of the client, e.g. to book a batch of requests ahead of an
+// operation such as listing every page of a collection. The caller must wait
+// for the Delay of the reservation before starting the operation. If the
+// operation is aborted before the Delay has elapsed, calling Cancel on the
+// reservation returns its tokens to the limiter; once the Delay has elapsed
+// the tokens are spent.
+//
+// Reserve requires the TokenBucket throttle and n to be at most
+// RequestsBurst.
+func (c *CombinedConfig) Reserve(n int) (*rate.Reservation, error) {
+	if c.limiter == nil {
+		return nil, fmt.Errorf("unable to reserve %d requests: no client-side rate limit is configured", n)
+	}
+
+	limiter, ok := c.limiter.(*rate.Limiter)
+	if !ok {
+		return nil, fmt.Errorf("unable to reserve %d requests: reservations require the %q throttle", n, TokenBucket)
+	}
+
+	reservation := limiter.ReserveN(time.Now(), n)
+	if !reservation.OK() {
+		return nil, fmt.Errorf("unable to reserve %d requests: exceeds the burst of %d", n, limiter.Burst())
+	}
+	return reservation, nil
+}
@@ -0,0 +1,398 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestLeakyBucket_Spacing(t *testing.T) {
+	bucket := newLeakyBucket(20)
+
+	var times []time.Time
+	for i := 0; i < 5; i++ {
+		if err := bucket.WaitN(context.Background(), 1); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		times = append(times, time.Now())
+	}
+
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 45*time.Millisecond {
+			t.Fatalf("Expected requests spaced by ~50ms, got %s between %d and %d", gap, i-1, i)
+		}
+	}
+}
+
+func TestLeakyBucket_NoBurstAfterIdle(t *testing.T) {
+	bucket := newLeakyBucket(20)
+	bucket.WaitN(context.Background(), 1)
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		bucket.WaitN(context.Background(), 1)
+	}
+
+	// A token bucket with burst would release these immediately.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("Expected idle time not to allow a burst, took %s", elapsed)
+	}
+}
+
+func TestLeakyBucket_Canceled(t *testing.T) {
+	bucket := newLeakyBucket(1)
+	bucket.WaitN(context.Background(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := bucket.WaitN(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestNewRequestLimiter(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Config    Config
+		Expected  string
+		ExpectErr bool
+	}{
+		{
+			Name:     "disabled",
+			Config:   Config{},
+			Expected: "<nil>",
+		},
+		{
+			Name:     "token bucket default",
+			Config:   Config{RequestsPerSecond: 10},
+			Expected: "*rate.Limiter",
+		},
+		{
+			Name:     "leaky bucket",
+			Config:   Config{RequestsPerSecond: 10, ThrottleAlgorithm: LeakyBucket},
+			Expected: "*config.leakyBucket",
+		},
+		{
+			Name:      "unknown",
+			Config:    Config{RequestsPerSecond: 10, ThrottleAlgorithm: "fifo"},
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			limiter, err := newRequestLimiter(&tc.Config)
+			if tc.ExpectErr {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := typeName(limiter); got != tc.Expected {
+				t.Fatalf("Expected %s, got %s", tc.Expected, got)
+			}
+		})
+	}
+}
+
+func TestThrottle_TokenBucketBurst(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RequestsPerSecond = 5
+	conf.RequestsBurst = 3
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if burst := times[2].Sub(start); burst > 100*time.Millisecond {
+		t.Fatalf("Expected the first 3 requests to burst, took %s", burst)
+	}
+	if wait := times[3].Sub(times[2]); wait < 150*time.Millisecond {
+		t.Fatalf("Expected the 4th request to wait for a token, waited %s", wait)
+	}
+}
+
+func TestThrottleWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.RequestsPerSecond = 5
+	conf.RequestsBurst = 1
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var waits []time.Duration
+	for i := 0; i < 2; i++ {
+		resp, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		waits = append(waits, ThrottleWait(resp.Response))
+	}
+
+	if waits[0] > 50*time.Millisecond {
+		t.Fatalf("Expected the first request not to wait for a token, waited %s", waits[0])
+	}
+	if waits[1] < 150*time.Millisecond {
+		t.Fatalf("Expected the second request to wait for a token, waited %s", waits[1])
+	}
+
+	if wait := ThrottleWait(&http.Response{}); wait != 0 {
+		t.Fatalf("Expected no wait for a foreign response, got %s", wait)
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	cases := map[string]string{
+		"/v2/droplets":                "droplets",
+		"/v2/droplets/123/actions":    "droplets",
+		"/v2/kubernetes/clusters/abc": "kubernetes",
+		"/v2/":                        "",
+	}
+
+	for path, expected := range cases {
+		if got := serviceName(path); got != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, path, got)
+		}
+	}
+}
+
+func TestThrottle_ServiceLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.ServiceRequestsPerSecond = map[string]float64{"droplets": 10}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("Expected unthrottled services not to wait, took %s", elapsed)
+	}
+
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		doRequest(t, client.GodoClient(), http.MethodGet, "/v2/droplets")
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Fatalf("Expected droplets requests to be throttled, took %s", elapsed)
+	}
+}
+
+func TestRequestCost(t *testing.T) {
+	costs := map[string]int{
+		"/v2/kubernetes":          5,
+		"/v2/kubernetes/clusters": 3,
+		"/v2/droplets":            2,
+	}
+
+	cases := []struct {
+		Path     string
+		Expected int
+	}{
+		{Path: "/v2/account", Expected: 1},
+		{Path: "/v2/droplets/123", Expected: 2},
+		{Path: "/v2/kubernetes/options", Expected: 5},
+		{Path: "/v2/kubernetes/clusters/abc", Expected: 3},
+	}
+
+	for _, tc := range cases {
+		if cost := requestCost(costs, tc.Path); cost != tc.Expected {
+			t.Fatalf("Expected a cost of %d for %s, got %d", tc.Expected, tc.Path, cost)
+		}
+	}
+}
+
+func TestThrottleTransport_RequestCosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.RequestsPerSecond = 0.001
+	c.RequestsBurst = 10
+	c.RequestCosts = map[string]int{"/v2/kubernetes": 4}
+
+	transport, err := newThrottleTransport(c, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	limiter := transport.(*throttleTransport).limiter.(*rate.Limiter)
+
+	for _, path := range []string{"/v2/account", "/v2/kubernetes/clusters"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	// One token for the unlisted path and four for the weighted one.
+	if tokens := limiter.Tokens(); tokens < 4.9 || tokens > 5.1 {
+		t.Fatalf("Expected 5 tokens left in the bucket, got %f", tokens)
+	}
+}
+
+func TestNewThrottleTransport_InvalidRequestCosts(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Costs map[string]int
+	}{
+		{Name: "zero cost", Costs: map[string]int{"/v2/droplets": 0}},
+		{Name: "above burst", Costs: map[string]int{"/v2/droplets": 3}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			c.RequestsPerSecond = 1
+			c.RequestsBurst = 2
+			c.RequestCosts = tc.Costs
+
+			if _, err := newThrottleTransport(c, http.DefaultTransport); err == nil {
+				t.Fatalf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestCombinedConfig_Reserve(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Cancel      bool
+		ExpectedMin time.Duration
+		ExpectedMax time.Duration
+	}{
+		// The request waits for the reserved tokens to be replenished, then
+		// for its own.
+		{Name: "reserved", ExpectedMin: 500 * time.Millisecond, ExpectedMax: time.Second},
+		{Name: "canceled", Cancel: true, ExpectedMin: 50 * time.Millisecond, ExpectedMax: 400 * time.Millisecond},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.RequestsPerSecond = 10
+			c.RequestsBurst = 5
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			now, err := client.Reserve(5)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if delay := now.Delay(); delay != 0 {
+				t.Fatalf("Expected the burst to be available at once, got a delay of %s", delay)
+			}
+
+			later, err := client.Reserve(5)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if delay := later.Delay(); delay < 400*time.Millisecond {
+				t.Fatalf("Expected the second batch to wait for the first, got a delay of %s", delay)
+			}
+			if tc.Cancel {
+				later.Cancel()
+			}
+
+			start := time.Now()
+			if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if waited := time.Since(start); waited < tc.ExpectedMin || waited > tc.ExpectedMax {
+				t.Fatalf("Expected the request to wait between %s and %s, got %s", tc.ExpectedMin, tc.ExpectedMax, waited)
+			}
+		})
+	}
+}
+
+func TestCombinedConfig_ReserveErrors(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Modify func(c *Config)
+		N      int
+		Error  string
+	}{
+		{Name: "no limit", Modify: func(c *Config) {}, N: 1, Error: "no client-side rate limit"},
+		{
+			Name: "leaky bucket",
+			Modify: func(c *Config) {
+				c.RequestsPerSecond = 10
+				c.ThrottleAlgorithm = LeakyBucket
+			},
+			N:     1,
+			Error: "require the \"token_bucket\" throttle",
+		},
+		{
+			Name: "beyond burst",
+			Modify: func(c *Config) {
+				c.RequestsPerSecond = 10
+				c.RequestsBurst = 5
+			},
+			N:     6,
+			Error: "exceeds the burst of 5",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			tc.Modify(c)
+
+			client, err := c.Client()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if _, err := client.Reserve(tc.N); err == nil || !strings.Contains(err.Error(), tc.Error) {
+				t.Fatalf("Expected an error containing %q, got %v", tc.Error, err)
+			}
+		})
+	}
+}
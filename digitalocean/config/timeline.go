@@ -0,0 +1,68 @@
+package config
+
+import (
+	"net/http"
+	"time"
+)
+
+// Attempt describes one attempt of a request.
+type Attempt struct {
+	// Time is when the outcome of the attempt was known.
+	Time       time.Time
+	StatusCode int
+	Err        error
+	// Sleep is the backoff before the next attempt, or zero for the last
+	// one. For attempts that failed without a response, it is measured as
+	// the gap before the next attempt.
+	Sleep time.Duration
+}
+
+// RetryTimeline returns the attempts made to obtain resp, oldest first. It
+// returns nil for responses that were not returned by a client built by
+// Config.Client.
+func RetryTimeline(resp *http.Response) []Attempt {
+	state := responseState(resp)
+	if state == nil {
+		return nil
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return append([]Attempt(nil), state.timeline...)
+}
+
+// recordAttempt counts an attempt and appends its outcome to the timeline of
+// state.
+func (s *requestState) recordAttempt(resp *http.Response, err error) {
+	attempt := Attempt{Time: time.Now(), Err: err}
+	if resp != nil {
+		attempt.StatusCode = resp.StatusCode
+	}
+
+	s.mu.Lock()
+	s.attempts++
+	s.timeline = append(s.timeline, attempt)
+	s.mu.Unlock()
+}
+
+// recordSleep sets the backoff after the last attempt of state.
+func (s *requestState) recordSleep(sleep time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.timeline); n > 0 {
+		s.timeline[n-1].Sleep = sleep
+	}
+}
+
+// startAttempt is called before every retry. It fills in the backoff of a
+// previous attempt that failed without a response, for which the backoff
+// could not be attributed to the request.
+func (s *requestState) startAttempt() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.timeline); n > 0 && s.timeline[n-1].StatusCode == 0 && s.timeline[n-1].Sleep == 0 {
+		s.timeline[n-1].Sleep = time.Since(s.timeline[n-1].Time)
+	}
+}
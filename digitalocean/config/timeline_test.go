@@ -0,0 +1,105 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTimeline(t *testing.T) {
+	statuses := []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusOK}
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[atomic.AddInt32(&attempts, 1)-1])
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	sleeps := []time.Duration{3 * time.Millisecond, 2 * time.Millisecond}
+	conf.OverrideBackoff = func(min, max time.Duration, attempt int, resp *http.Response) (time.Duration, bool) {
+		return sleeps[attempt], true
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	start := time.Now()
+	resp, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	timeline := RetryTimeline(resp.Response)
+	if len(timeline) != len(statuses) {
+		t.Fatalf("Expected %d attempts, got %+v", len(statuses), timeline)
+	}
+	for i, attempt := range timeline {
+		if attempt.StatusCode != statuses[i] {
+			t.Fatalf("Expected attempt %d to have status %d, got %d", i, statuses[i], attempt.StatusCode)
+		}
+		if attempt.Err != nil {
+			t.Fatalf("Expected attempt %d to have no error, got %s", i, attempt.Err)
+		}
+		if attempt.Time.Before(start) {
+			t.Fatalf("Expected attempt %d to be timed after the request started", i)
+		}
+		if i > 0 && attempt.Time.Before(timeline[i-1].Time.Add(timeline[i-1].Sleep)) {
+			t.Fatalf("Expected attempt %d to follow the previous backoff", i)
+		}
+
+		expected := time.Duration(0)
+		if i < len(sleeps) {
+			expected = sleeps[i]
+		}
+		if attempt.Sleep != expected {
+			t.Fatalf("Expected attempt %d to sleep %s, got %s", i, expected, attempt.Sleep)
+		}
+	}
+}
+
+func TestRetryTimeline_ConnectionErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := testConfig(server.URL).Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	timeline := RetryTimeline(resp.Response)
+	if len(timeline) != 2 {
+		t.Fatalf("Expected 2 attempts, got %+v", timeline)
+	}
+	if timeline[0].Err == nil || timeline[0].StatusCode != 0 {
+		t.Fatalf("Expected the first attempt to fail without a response, got %+v", timeline[0])
+	}
+	if timeline[0].Sleep <= 0 {
+		t.Fatalf("Expected the first attempt to record its backoff, got %s", timeline[0].Sleep)
+	}
+	if timeline[1].StatusCode != http.StatusOK || timeline[1].Sleep != 0 {
+		t.Fatalf("Expected the last attempt to succeed without a backoff, got %+v", timeline[1])
+	}
+}
+
+func TestRetryTimeline_UnknownResponse(t *testing.T) {
+	if timeline := RetryTimeline(&http.Response{}); timeline != nil {
+		t.Fatalf("Expected no timeline, got %+v", timeline)
+	}
+}
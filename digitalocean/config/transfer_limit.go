@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrTransferLimitExceeded is returned for requests made once the bytes
+// transferred by the client have exceeded MaxTotalBytes. Such requests are
+// never retried.
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// transferLimitTransport counts the request and response body bytes of every
+// attempt and rejects requests once more than max bytes have been
+// transferred. It sits below the retrying transport, so retries are counted
+// too.
+type transferLimitTransport struct {
+	base        http.RoundTripper
+	max         int64
+	transferred int64
+}
+
+func (t *transferLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transferred := atomic.LoadInt64(&t.transferred); transferred > t.max {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %d bytes transferred, limit is %d", ErrTransferLimitExceeded, transferred, t.max)
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &countingReadCloser{ReadCloser: req.Body, count: &t.transferred}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Body != nil && resp.Body != http.NoBody {
+		resp.Body = &countingReadCloser{ReadCloser: resp.Body, count: &t.transferred}
+	}
+	return resp, nil
+}
+
+// countingReadCloser adds the number of bytes read to count.
+type countingReadCloser struct {
+	io.ReadCloser
+	count *int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.count, int64(n))
+	return n, err
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_MaxTotalBytes(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(strings.Repeat("x", 400)))
+	}))
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	conf.MaxTotalBytes = 1000
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// 400, 800 and 1200 bytes: the request crossing the limit still succeeds.
+	for i := 0; i < 3; i++ {
+		if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+			t.Fatalf("unexpected error on request %d: %s", i, err)
+		}
+	}
+
+	_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account")
+	if !errors.Is(err, ErrTransferLimitExceeded) {
+		t.Fatalf("Expected ErrTransferLimitExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "1200 bytes transferred, limit is 1000") {
+		t.Fatalf("Expected a descriptive error, got %s", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("Expected the request over the limit to fail before reaching the server without retries, got %d requests", n)
+	}
+}
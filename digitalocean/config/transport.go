@@ -0,0 +1,70 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// configureBaseTransport applies the connection options of c to the transport
+// that sends requests over the network. Transports other than *http.Transport
+// are left untouched.
+func configureBaseTransport(c *Config, base http.RoundTripper) {
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	transport.TLSClientConfig = withMinTLSVersion(transport.TLSClientConfig, c.minTLSVersion())
+
+	if c.ForceHTTP1 {
+		// A non-nil, empty TLSNextProto disables HTTP/2.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if c.DisableKeepAlives {
+		transport.DisableKeepAlives = true
+	}
+
+	if len(c.LocalAddresses) > 0 {
+		// Validate has already rejected malformed addresses.
+		if addrs, err := parseLocalAddresses(c.LocalAddresses); err == nil {
+			transport.DialContext = (&localAddrDialer{addrs: addrs}).DialContext
+		}
+	}
+}
+
+// minTLSVersion returns the lowest TLS version that may be negotiated.
+func (c *Config) minTLSVersion() uint16 {
+	if c.MinTLSVersion == 0 {
+		return tls.VersionTLS12
+	}
+	return c.MinTLSVersion
+}
+
+// withMinTLSVersion returns a copy of config, which may be nil, that does not
+// negotiate a TLS version below min.
+func withMinTLSVersion(config *tls.Config, min uint16) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if config.MinVersion < min {
+		config.MinVersion = min
+	}
+	return config
+}
+
+// validateTLSVersion checks that version is TLS 1.2 or later, or zero for
+// the default. TLS 1.0 and 1.1 are deprecated and rejected.
+func validateTLSVersion(version uint16) error {
+	switch version {
+	case 0, tls.VersionTLS12, tls.VersionTLS13:
+		return nil
+	case tls.VersionTLS10, tls.VersionTLS11:
+		return fmt.Errorf("minimum TLS version 0x%04x is insecure, expected TLS 1.2 or later", version)
+	}
+	return fmt.Errorf("unknown minimum TLS version 0x%04x", version)
+}
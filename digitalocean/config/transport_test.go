@@ -0,0 +1,89 @@
+package config
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConfigureBaseTransport(t *testing.T) {
+	cases := []struct {
+		Name              string
+		ForceHTTP1        bool
+		DisableKeepAlives bool
+	}{
+		{Name: "defaults"},
+		{Name: "force HTTP/1.1", ForceHTTP1: true},
+		{Name: "disable keep-alives", DisableKeepAlives: true},
+		{Name: "both", ForceHTTP1: true, DisableKeepAlives: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			c.ForceHTTP1 = tc.ForceHTTP1
+			c.DisableKeepAlives = tc.DisableKeepAlives
+
+			transport := &http.Transport{ForceAttemptHTTP2: true}
+			configureBaseTransport(c, transport)
+
+			if transport.ForceAttemptHTTP2 == tc.ForceHTTP1 {
+				t.Fatalf("Expected ForceAttemptHTTP2 to be %t", !tc.ForceHTTP1)
+			}
+			if http2Disabled := transport.TLSNextProto != nil && len(transport.TLSNextProto) == 0; http2Disabled != tc.ForceHTTP1 {
+				t.Fatalf("Expected HTTP/2 disabled to be %t", tc.ForceHTTP1)
+			}
+			if transport.DisableKeepAlives != tc.DisableKeepAlives {
+				t.Fatalf("Expected DisableKeepAlives to be %t", tc.DisableKeepAlives)
+			}
+		})
+	}
+}
+
+func TestConfigureBaseTransport_MinTLSVersion(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Version  uint16
+		Expected uint16
+	}{
+		{Name: "default", Expected: tls.VersionTLS12},
+		{Name: "TLS 1.3", Version: tls.VersionTLS13, Expected: tls.VersionTLS13},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			c.MinTLSVersion = tc.Version
+
+			transport := &http.Transport{}
+			configureBaseTransport(c, transport)
+
+			if transport.TLSClientConfig == nil || transport.TLSClientConfig.MinVersion != tc.Expected {
+				t.Fatalf("Expected a minimum TLS version of 0x%04x, got %+v", tc.Expected, transport.TLSClientConfig)
+			}
+		})
+	}
+}
+
+func TestConfigureBaseTransport_RejectsWeakTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS11}
+	server.StartTLS()
+	defer server.Close()
+
+	// The server's client trusts its certificate.
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	configureBaseTransport(testConfig(server.URL), transport)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := transport.RoundTrip(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected the handshake to fail")
+	}
+	if !strings.Contains(err.Error(), "protocol version") {
+		t.Fatalf("Expected a protocol version error, got %s", err)
+	}
+}
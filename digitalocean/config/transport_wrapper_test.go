@@ -0,0 +1,51 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type countingTransport struct {
+	base  http.RoundTripper
+	calls int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.calls, 1)
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Wrapped", "true")
+	return t.base.RoundTrip(req)
+}
+
+func TestTransportWrapper(t *testing.T) {
+	var wrapped string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapped = r.Header.Get("X-Wrapped")
+	}))
+	defer server.Close()
+
+	var tracer *countingTransport
+	conf := testConfig(server.URL)
+	conf.TransportWrapper = func(base http.RoundTripper) http.RoundTripper {
+		tracer = &countingTransport{base: base}
+		return tracer
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/account"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if tracer == nil || tracer.calls != 1 {
+		t.Fatal("Expected the wrapper to be invoked once")
+	}
+	if wrapped != "true" {
+		t.Fatal("Expected the request to pass through the wrapper")
+	}
+}
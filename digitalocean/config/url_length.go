@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// urlLengthTransport rejects requests whose URL is longer than max before
+// they reach the API, where gateways fail them with an opaque 414.
+type urlLengthTransport struct {
+	base http.RoundTripper
+	max  int
+}
+
+func (t *urlLengthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if length := len(req.URL.String()); length > t.max {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%s %s: URL is %d characters long, exceeding the maximum of %d",
+			req.Method, req.URL.Path, length, t.max)
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_MaxURLLength(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	c := testConfig(server.URL)
+	c.MaxURLLength = 256
+
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if _, err := doRequest(t, client.GodoClient(), http.MethodGet, "/v2/droplets?tag_name=web"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	_, err = doRequest(t, client.GodoClient(), http.MethodGet, "/v2/droplets?tag_name="+strings.Repeat("a", 256))
+	if err == nil || !strings.Contains(err.Error(), "GET /v2/droplets") {
+		t.Fatalf("Expected an error naming the request, got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("Expected the over-long request not to be sent, got %d requests", n)
+	}
+}
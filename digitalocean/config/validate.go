@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// Validate checks c for errors without contacting the network. All problems
+// found are reported together.
+func (c *Config) Validate() error {
+	var result *multierror.Error
+
+	if c.TokenSource == nil && c.OAuthConfig == nil && c.TokenFile == "" && c.Token == "" {
+		result = multierror.Append(result, errNoCredentials)
+	}
+
+	if err := validateEndpoint(c.APIEndpoint); err != nil {
+		result = multierror.Append(result, fmt.Errorf("invalid api_endpoint: %s", err))
+	}
+
+	if err := validateSpacesEndpoint(c.SpacesAPIEndpoint); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if c.SpacesRequired && (c.AccessID == "" || c.SecretKey == "") {
+		result = multierror.Append(result, errSpacesCredentialsMissing)
+	}
+
+	if c.HTTPRetryWaitMin < 0 || c.HTTPRetryWaitMax < 0 {
+		result = multierror.Append(result, fmt.Errorf("http_retry_wait_min and http_retry_wait_max must not be negative"))
+	} else if c.HTTPRetryWaitMin > c.HTTPRetryWaitMax {
+		result = multierror.Append(result, fmt.Errorf("http_retry_wait_min (%g) must not exceed http_retry_wait_max (%g)",
+			c.HTTPRetryWaitMin, c.HTTPRetryWaitMax))
+	}
+
+	switch c.BackoffStrategy {
+	case "", ExponentialBackoff, LinearJitterBackoff:
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown backoff strategy %q, expected %q or %q",
+			c.BackoffStrategy, ExponentialBackoff, LinearJitterBackoff))
+	}
+
+	switch c.ThrottleAlgorithm {
+	case "", TokenBucket, LeakyBucket:
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown throttle algorithm %q, expected %q or %q",
+			c.ThrottleAlgorithm, TokenBucket, LeakyBucket))
+	}
+
+	for status, limit := range c.RetryLimitsByStatus {
+		if limit < 0 {
+			result = multierror.Append(result, fmt.Errorf("retry limit of status %d must not be negative, got %d", status, limit))
+		}
+	}
+
+	if err := validateRequestCosts(c); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if c.AcceptHeader != "" {
+		if err := validateAcceptHeader(c.AcceptHeader); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	if c.RetryLogger != nil {
+		switch c.RetryLogger.(type) {
+		case retryablehttp.Logger, retryablehttp.LeveledLogger:
+		default:
+			result = multierror.Append(result, fmt.Errorf("RetryLogger must implement retryablehttp.Logger or retryablehttp.LeveledLogger, got %T", c.RetryLogger))
+		}
+	}
+
+	for i, wait := range c.BackoffSchedule {
+		if wait < 0 {
+			result = multierror.Append(result, fmt.Errorf("entry %d of the backoff schedule must not be negative, got %s", i, wait))
+		}
+	}
+
+	for workspace, weight := range c.WorkspaceWeights {
+		if weight <= 0 {
+			result = multierror.Append(result, fmt.Errorf("weight of workspace %q must be positive, got %g", workspace, weight))
+		}
+	}
+
+	if err := validateTLSVersion(c.MinTLSVersion); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if _, err := parseLocalAddresses(c.LocalAddresses); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if c.ReplayFrom != "" && c.RecordTo != "" {
+		result = multierror.Append(result, fmt.Errorf("RecordTo and ReplayFrom cannot be used together"))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if len(result.Errors) == 1 {
+		return result.Errors[0]
+	}
+	return result
+}
+
+// validateEndpoint checks that endpoint is an absolute http or https URL.
+func validateEndpoint(endpoint string) error {
+	_, err := parseEndpoint(endpoint)
+	return err
+}
+
+// parseEndpoint parses an http or https endpoint URL and lowercases its
+// scheme.
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	switch u.Scheme {
+	case "http", "https":
+	case "":
+		return nil, fmt.Errorf("%q has no scheme, expected e.g. \"https://%s\"", endpoint, endpoint)
+	default:
+		// "host:port" parses as a URL whose scheme is the host.
+		return nil, fmt.Errorf("%q has unsupported scheme %q, expected \"http\" or \"https\"", endpoint, u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("%q has no host", endpoint)
+	}
+	return u, nil
+}
+
+// validateSpacesEndpoint checks that the spaces_endpoint template parses and
+// renders to an absolute URL.
+func validateSpacesEndpoint(endpoint string) error {
+	tmpl, err := template.New("spaces").Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("unable to parse spaces_endpoint '%s' as template: %s", endpoint, err)
+	}
+
+	rendered := strings.Builder{}
+	if err := tmpl.Execute(&rendered, map[string]string{"Region": "nyc3"}); err != nil {
+		return fmt.Errorf("unable to render spaces_endpoint '%s': %s", endpoint, err)
+	}
+
+	if err := validateEndpoint(rendered.String()); err != nil {
+		return fmt.Errorf("invalid spaces_endpoint: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Modify func(c *Config)
+		Error  string
+	}{
+		{
+			Name:   "valid",
+			Modify: func(c *Config) {},
+		},
+		{
+			Name:   "no credentials",
+			Modify: func(c *Config) { c.Token = "" },
+			Error:  "no DigitalOcean API credentials configured",
+		},
+		{
+			Name:   "relative api endpoint",
+			Modify: func(c *Config) { c.APIEndpoint = "api.digitalocean.com" },
+			Error:  "invalid api_endpoint",
+		},
+		{
+			Name:   "malformed api endpoint",
+			Modify: func(c *Config) { c.APIEndpoint = "https://api digitalocean com/%zz" },
+			Error:  "invalid api_endpoint",
+		},
+		{
+			Name:   "unparsable spaces template",
+			Modify: func(c *Config) { c.SpacesAPIEndpoint = "https://{{.Region}.digitaloceanspaces.com" },
+			Error:  "unable to parse spaces_endpoint",
+		},
+		{
+			Name:   "unrenderable spaces template",
+			Modify: func(c *Config) { c.SpacesAPIEndpoint = "https://{{.Region.Name}}.digitaloceanspaces.com" },
+			Error:  "unable to render spaces_endpoint",
+		},
+		{
+			Name:   "relative spaces endpoint",
+			Modify: func(c *Config) { c.SpacesAPIEndpoint = "{{.Region}}.digitaloceanspaces.com" },
+			Error:  "invalid spaces_endpoint",
+		},
+		{
+			Name: "missing spaces credentials",
+			Modify: func(c *Config) {
+				c.SpacesRequired = true
+			},
+			Error: "Spaces credentials not configured",
+		},
+		{
+			Name:   "negative retry wait",
+			Modify: func(c *Config) { c.HTTPRetryWaitMin = -1 },
+			Error:  "must not be negative",
+		},
+		{
+			Name:   "inconsistent retry waits",
+			Modify: func(c *Config) { c.HTTPRetryWaitMin = 30 },
+			Error:  "must not exceed http_retry_wait_max",
+		},
+		{
+			Name:   "negative backoff schedule entry",
+			Modify: func(c *Config) { c.BackoffSchedule = []time.Duration{time.Second, -time.Second} },
+			Error:  "entry 1 of the backoff schedule must not be negative",
+		},
+		{
+			Name:   "unknown backoff strategy",
+			Modify: func(c *Config) { c.BackoffStrategy = "fibonacci" },
+			Error:  "unknown backoff strategy",
+		},
+		{
+			Name:   "unknown throttle algorithm",
+			Modify: func(c *Config) { c.ThrottleAlgorithm = "sliding_window" },
+			Error:  "unknown throttle algorithm",
+		},
+		{
+			Name:   "invalid request cost",
+			Modify: func(c *Config) { c.RequestCosts = map[string]int{"/v2/droplets": 0} },
+			Error:  "request cost",
+		},
+		{
+			Name:   "negative retry limit",
+			Modify: func(c *Config) { c.RetryLimitsByStatus = map[int]int{500: -1} },
+			Error:  "retry limit of status 500 must not be negative",
+		},
+		{
+			Name:   "invalid accept header",
+			Modify: func(c *Config) { c.AcceptHeader = "json" },
+			Error:  "invalid Accept header",
+		},
+		{
+			Name:   "invalid retry logger",
+			Modify: func(c *Config) { c.RetryLogger = "stdout" },
+			Error:  "RetryLogger must implement",
+		},
+		{
+			Name: "record and replay",
+			Modify: func(c *Config) {
+				c.RecordTo = "recording.jsonl"
+				c.ReplayFrom = "recording.jsonl"
+			},
+			Error: "cannot be used together",
+		},
+		{
+			Name:   "non-positive workspace weight",
+			Modify: func(c *Config) { c.WorkspaceWeights = map[string]float64{"staging": 0} },
+			Error:  `weight of workspace "staging" must be positive`,
+		},
+		{
+			Name:   "unknown TLS version",
+			Modify: func(c *Config) { c.MinTLSVersion = 0x0200 },
+			Error:  "unknown minimum TLS version 0x0200",
+		},
+		{
+			Name:   "TLS 1.1",
+			Modify: func(c *Config) { c.MinTLSVersion = tls.VersionTLS11 },
+			Error:  "minimum TLS version 0x0302 is insecure",
+		},
+		{
+			Name:   "invalid local address",
+			Modify: func(c *Config) { c.LocalAddresses = []string{"127.0.0.1", "localhost"} },
+			Error:  `invalid local address "localhost"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := testConfig("https://api.digitalocean.com")
+			tc.Modify(c)
+
+			err := c.Validate()
+			if tc.Error == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.Error) {
+				t.Fatalf("Expected an error containing %q, got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateCombinesErrors(t *testing.T) {
+	c := testConfig("api.digitalocean.com")
+	c.Token = ""
+	c.HTTPRetryWaitMin = -1
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	for _, expected := range []string{"3 errors occurred", "no DigitalOcean API credentials", "invalid api_endpoint", "http_retry_wait_min"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected the error to contain %q, got %s", expected, err)
+		}
+	}
+
+	if _, clientErr := c.Client(); clientErr == nil || clientErr.Error() != err.Error() {
+		t.Fatalf("Expected Client() to return the validation error, got %v", clientErr)
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import "context"
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a context whose requests are accounted to workspace
+// when WorkspaceFairQueuing is enabled.
+func WithWorkspace(ctx context.Context, workspace string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspace)
+}
+
+// workspaceFrom returns the workspace attached to ctx, or "" for requests
+// that are not tagged.
+func workspaceFrom(ctx context.Context) string {
+	workspace, _ := ctx.Value(workspaceKey{}).(string)
+	return workspace
+}
+
+// fairQueue computes weighted fair queuing finish tags, so that workspaces
+// share the rate limit in proportion to their weights. A request's tag
+// advances its workspace's virtual clock by cost/weight, starting from the
+// tag of the last dispatched request if the workspace was idle. It is guarded
+// by the scheduler's lock.
+type fairQueue struct {
+	weights map[string]float64
+	virtual float64
+	finish  map[string]float64
+}
+
+func newFairQueue(weights map[string]float64) *fairQueue {
+	return &fairQueue{weights: weights, finish: map[string]float64{}}
+}
+
+// tag returns the finish tag of a request of cost from workspace.
+func (q *fairQueue) tag(workspace string, cost int) float64 {
+	weight := q.weights[workspace]
+	if weight <= 0 {
+		weight = 1
+	}
+
+	start := q.finish[workspace]
+	if start < q.virtual {
+		start = q.virtual
+	}
+	finish := start + float64(cost)/weight
+	q.finish[workspace] = finish
+	return finish
+}
+
+// dispatched advances the virtual clock to the tag of a dispatched request.
+func (q *fairQueue) dispatched(finish float64) {
+	if finish > q.virtual {
+		q.virtual = finish
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottleTransport_WorkspaceFairQueuing(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Weights  map[string]float64
+		Expected string
+	}{
+		{
+			Name:     "equal weights",
+			Expected: "big,big,big,small,big,small,big,small,big,big",
+		},
+		{
+			Name:     "weighted",
+			Weights:  map[string]float64{"small": 2},
+			Expected: "big,big,small,big,small,small,big,big,big,big",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var mu sync.Mutex
+			var order []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				order = append(order, r.URL.Query().Get("workspace"))
+				mu.Unlock()
+			}))
+			defer server.Close()
+
+			c := testConfig(server.URL)
+			c.RequestsPerSecond = 20
+			c.WorkspaceFairQueuing = true
+			c.WorkspaceWeights = tc.Weights
+
+			transport, err := newThrottleTransport(c, http.DefaultTransport)
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+
+			send := func(workspace string) {
+				ctx := WithWorkspace(context.Background(), workspace)
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v2/droplets?workspace="+workspace, nil)
+				resp, err := transport.RoundTrip(req)
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+					return
+				}
+				resp.Body.Close()
+			}
+
+			// The first request of the big workspace takes the only token
+			// and the second holds the turn while it waits for the next,
+			// while the big workspace queues a batch ahead of the small one.
+			var wg sync.WaitGroup
+			workspaces := []string{"big", "big", "big", "big", "big", "big", "big", "small", "small", "small"}
+			for _, workspace := range workspaces {
+				wg.Add(1)
+				go func(workspace string) {
+					defer wg.Done()
+					send(workspace)
+				}(workspace)
+				time.Sleep(3 * time.Millisecond)
+			}
+			wg.Wait()
+
+			if got := strings.Join(order, ","); got != tc.Expected {
+				t.Fatalf("Expected order %s, got %s", tc.Expected, got)
+			}
+		})
+	}
+}
@@ -77,7 +77,7 @@ func Provider() *schema.Provider {
 				Type:        schema.TypeInt,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("DIGITALOCEAN_HTTP_RETRY_MAX", 0),
-				Description: "The maximum number of retries on a failed API request.",
+				Description: "The maximum number of retries on a failed API request. A negative value uses the default of 4 retries.",
 			},
 			"http_retry_wait_min": {
 				Type:        schema.TypeFloat,
@@ -199,6 +199,8 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 		HTTPRetryWaitMin:  d.Get("http_retry_wait_min").(float64),
 		HTTPRetryWaitMax:  d.Get("http_retry_wait_max").(float64),
 		TerraformVersion:  terraformVersion,
+		// Spaces requests keep the retries of the SDK.
+		SpacesHTTPRetryMax: -1,
 	}
 
 	if endpoint, ok := d.GetOk("spaces_endpoint"); ok {